@@ -0,0 +1,407 @@
+// Command jin-invokergen generates inject.FastInvoker adapters for the handler
+// signatures a package passes to jin, so that they are invoked without reflect.Call.
+//
+// It type-checks the package in the given directory, the current one by default,
+// collects the types of the functions passed as jin.HandlerFunc, to the routing
+// methods, Use, PassErrors, Engine.Provide and so on, and writes jin_invokers_gen.go
+// which registers an adapter for each of them with jin.RegisterInvoker. Add to a file
+// of the package:
+//
+//	//go:generate go run github.com/juanjiTech/jin/cmd/jin-invokergen
+//
+// Signatures already handled by jin, variadic functions and functions using
+// unexported types of other packages are skipped.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	jinPath    = "github.com/juanjiTech/jin"
+	injectPath = "github.com/juanjiTech/inject/v2"
+)
+
+var output = flag.String("o", "jin_invokers_gen.go", "name of the generated file")
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("jin-invokergen: ")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: jin-invokergen [-o file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	} else if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	src, err := generate(dir, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the source of the adapters for the package in dir, ignoring the
+// previously generated file.
+func generate(dir, output string) ([]byte, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		if name == output {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	pkg, err := conf.Check(bp.ImportPath, fset, files, info)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGenerator(pkg)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				g.collect(info, call)
+			}
+			return true
+		})
+	}
+	return g.source()
+}
+
+type generator struct {
+	pkg *types.Package
+	// signatures are the handler signatures found, in order of appearance.
+	signatures []*types.Signature
+	seen       map[string]bool
+	// imports maps the import paths to the names they are referred to with.
+	imports map[string]string
+	// aliased holds the import paths which are referred to with another name than
+	// the one of their package.
+	aliased map[string]bool
+	names   map[string]bool
+}
+
+func newGenerator(pkg *types.Package) *generator {
+	g := &generator{
+		pkg:     pkg,
+		seen:    make(map[string]bool),
+		imports: make(map[string]string),
+		aliased: make(map[string]bool),
+		names:   make(map[string]bool),
+	}
+	for _, name := range pkg.Scope().Names() {
+		g.names[name] = true
+	}
+	g.importName("reflect", "reflect", false)
+	g.importName(injectPath, "inject", false)
+	if pkg.Path() != jinPath {
+		g.importName(jinPath, "jin", false)
+	}
+	return g
+}
+
+// collect records the signatures of the functions passed as jin.HandlerFunc to call.
+func (g *generator) collect(info *types.Info, call *ast.CallExpr) {
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	case *ast.IndexExpr:
+		// instantiated generic function
+		return
+	default:
+		return
+	}
+	fn, ok := info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != jinPath {
+		return
+	}
+	sig := fn.Type().(*types.Signature)
+	params := sig.Params()
+	for i, arg := range call.Args {
+		var param types.Type
+		switch {
+		case sig.Variadic() && i >= params.Len()-1:
+			if call.Ellipsis.IsValid() {
+				return
+			}
+			param = params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+		case i < params.Len():
+			param = params.At(i).Type()
+		default:
+			return
+		}
+		if !isHandlerFunc(param) {
+			continue
+		}
+		// the invokers are looked up by the exact type of the handler, so named
+		// func types like http.HandlerFunc are left alone
+		handler, ok := info.TypeOf(arg).(*types.Signature)
+		if !ok || !g.supported(handler) {
+			continue
+		}
+		handler = unnamed(handler)
+		key := types.TypeString(handler, nil)
+		if !g.seen[key] {
+			g.seen[key] = true
+			g.signatures = append(g.signatures, handler)
+		}
+	}
+}
+
+// unnamed returns sig without the names of its parameters and results.
+func unnamed(sig *types.Signature) *types.Signature {
+	strip := func(tuple *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, tuple.Len())
+		for i := range vars {
+			vars[i] = types.NewParam(tuple.At(i).Pos(), tuple.At(i).Pkg(), "", tuple.At(i).Type())
+		}
+		return types.NewTuple(vars...)
+	}
+	return types.NewSignatureType(nil, nil, nil, strip(sig.Params()), strip(sig.Results()), sig.Variadic())
+}
+
+func isHandlerFunc(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == jinPath && named.Obj().Name() == "HandlerFunc"
+}
+
+// supported reports whether an adapter can be written for the handler signature.
+func (g *generator) supported(sig *types.Signature) bool {
+	if sig.Variadic() || sig.TypeParams() != nil {
+		return false
+	}
+	switch types.TypeString(unnamed(sig), nil) {
+	case "func(*" + jinPath + ".Context)", "func(net/http.ResponseWriter, *net/http.Request)":
+		// already adapted by jin
+		return false
+	}
+	return g.expressible(sig, make(map[types.Type]bool))
+}
+
+// expressible reports whether t can be written in the generated package.
+func (g *generator) expressible(t types.Type, visiting map[types.Type]bool) bool {
+	if visiting[t] {
+		return true
+	}
+	visiting[t] = true
+
+	switch t := t.(type) {
+	case *types.Basic:
+		return t.Kind() != types.UnsafePointer && t.Kind() != types.Invalid
+	case *types.Alias:
+		return g.expressibleObj(t.Obj()) && g.expressible(types.Unalias(t), visiting)
+	case *types.Named:
+		if !g.expressibleObj(t.Obj()) {
+			return false
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if !g.expressible(t.TypeArgs().At(i), visiting) {
+				return false
+			}
+		}
+		return true
+	case *types.Pointer:
+		return g.expressible(t.Elem(), visiting)
+	case *types.Slice:
+		return g.expressible(t.Elem(), visiting)
+	case *types.Array:
+		return g.expressible(t.Elem(), visiting)
+	case *types.Chan:
+		return g.expressible(t.Elem(), visiting)
+	case *types.Map:
+		return g.expressible(t.Key(), visiting) && g.expressible(t.Elem(), visiting)
+	case *types.Signature:
+		if t.TypeParams() != nil {
+			return false
+		}
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				if !g.expressible(tuple.At(i).Type(), visiting) {
+					return false
+				}
+			}
+		}
+		return true
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			if !f.Exported() && f.Pkg() != g.pkg || !g.expressible(f.Type(), visiting) {
+				return false
+			}
+		}
+		return true
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			m := t.ExplicitMethod(i)
+			if !m.Exported() && m.Pkg() != g.pkg || !g.expressible(m.Type(), visiting) {
+				return false
+			}
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			if !g.expressible(t.EmbeddedType(i), visiting) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (g *generator) expressibleObj(obj *types.TypeName) bool {
+	// universe types like error have no package
+	return obj.Pkg() == nil || obj.Pkg() == g.pkg || obj.Exported()
+}
+
+// qualifier names the packages of the types written in the generated file.
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	if name, ok := g.imports[p.Path()]; ok {
+		return name
+	}
+	name := p.Name()
+	for i := 2; g.names[name]; i++ {
+		name = p.Name() + strconv.Itoa(i)
+	}
+	g.importName(p.Path(), name, name != p.Name())
+	return name
+}
+
+func (g *generator) importName(path, name string, aliased bool) {
+	g.imports[path] = name
+	g.aliased[path] = aliased
+	g.names[name] = true
+}
+
+// jin returns the qualified name of an identifier of package jin.
+func (g *generator) jin(name string) string {
+	if g.pkg.Path() == jinPath {
+		return name
+	}
+	return g.imports[jinPath] + "." + name
+}
+
+func (g *generator) source() ([]byte, error) {
+	var body bytes.Buffer
+	if len(g.signatures) > 0 {
+		body.WriteString("func init() {\n")
+		for i, sig := range g.signatures {
+			fmt.Fprintf(&body, "%s(func(h %s) %s.FastInvoker {\nreturn jinInvoker%d(h)\n})\n",
+				g.jin("RegisterInvoker"), types.TypeString(sig, g.qualifier), g.imports[injectPath], i)
+		}
+		body.WriteString("}\n")
+	}
+	for i, sig := range g.signatures {
+		g.writeInvoker(&body, i, sig)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by jin-invokergen. DO NOT EDIT.\n\npackage %s\n\n", g.pkg.Name())
+	if len(g.signatures) > 0 {
+		var std, others []string
+		for path := range g.imports {
+			if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+				others = append(others, path)
+			} else {
+				std = append(std, path)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(others)
+		buf.WriteString("import (\n")
+		for i, paths := range [][]string{std, others} {
+			if i > 0 && len(std) > 0 {
+				buf.WriteString("\n")
+			}
+			for _, path := range paths {
+				if g.aliased[path] {
+					fmt.Fprintf(&buf, "%s ", g.imports[path])
+				}
+				fmt.Fprintf(&buf, "%q\n", path)
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+func (g *generator) writeInvoker(buf *bytes.Buffer, i int, sig *types.Signature) {
+	name := fmt.Sprintf("jinInvoker%d", i)
+	reflect := g.imports["reflect"]
+	fmt.Fprintf(buf, "\ntype %s %s\n\n", name, types.TypeString(sig, g.qualifier))
+	fmt.Fprintf(buf, "func (invoke %s) Invoke(args []any) ([]%s.Value, error) {\n", name, reflect)
+
+	params := sig.Params()
+	args := make([]string, params.Len())
+	for j := 0; j < params.Len(); j++ {
+		t := params.At(j).Type()
+		typ := types.TypeString(t, g.qualifier)
+		if types.IsInterface(t) {
+			// a nil interface can be injected, like the error of a PassErrors handler
+			fmt.Fprintf(buf, "a%d, _ := args[%d].(%s)\n", j, j, typ)
+			args[j] = fmt.Sprintf("a%d", j)
+		} else {
+			args[j] = fmt.Sprintf("args[%d].(%s)", j, typ)
+		}
+	}
+
+	results := sig.Results()
+	call := fmt.Sprintf("invoke(%s)", strings.Join(args, ", "))
+	if results.Len() == 0 {
+		fmt.Fprintf(buf, "%s\nreturn nil, nil\n}\n", call)
+		return
+	}
+	vars := make([]string, results.Len())
+	values := make([]string, results.Len())
+	for j := range vars {
+		vars[j] = fmt.Sprintf("r%d", j)
+		// keep the static type of the result, reflect.ValueOf(nil) is invalid
+		values[j] = fmt.Sprintf("%s.ValueOf(&r%d).Elem()", reflect, j)
+	}
+	fmt.Fprintf(buf, "%s := %s\nreturn []%s.Value{%s}, nil\n}\n", strings.Join(vars, ", "), call, reflect, strings.Join(values, ", "))
+}
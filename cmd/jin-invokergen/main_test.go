@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("testdata", "app")
+	src, err := generate(dir, "jin_invokers_gen.go")
+	assert.NoError(t, err)
+
+	// regenerate with: go run ./cmd/jin-invokergen cmd/jin-invokergen/testdata/app
+	golden, err := os.ReadFile(filepath.Join(dir, "jin_invokers_gen.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), string(src))
+}
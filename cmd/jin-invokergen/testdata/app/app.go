@@ -0,0 +1,23 @@
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/juanjiTech/jin"
+)
+
+type User struct {
+	Name string
+}
+
+type store struct{}
+
+func Routes(r *jin.Engine) {
+	r.Provide(func(ctx context.Context) (*store, error) { return &store{}, nil })
+	r.Use(func(c *jin.Context) {}, jin.PassErrors(func(r *http.Request) (*User, error) { return nil, nil }))
+	r.GET("/user", func(u *User, err error) (int, *User) { return http.StatusOK, u })
+	r.POST("/user", func(s *store) {}, func(w http.ResponseWriter, r *http.Request) {})
+	r.Group("/v1").PUT("/user", func(u *User, err error) (int, *User) { return http.StatusOK, u }, http.HandlerFunc(nil))
+	r.GET("/variadic", func(names ...string) {})
+}
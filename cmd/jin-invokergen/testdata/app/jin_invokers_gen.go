@@ -0,0 +1,57 @@
+// Code generated by jin-invokergen. DO NOT EDIT.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/juanjiTech/inject/v2"
+	"github.com/juanjiTech/jin"
+)
+
+func init() {
+	jin.RegisterInvoker(func(h func(context.Context) (*store, error)) inject.FastInvoker {
+		return jinInvoker0(h)
+	})
+	jin.RegisterInvoker(func(h func(*http.Request) (*User, error)) inject.FastInvoker {
+		return jinInvoker1(h)
+	})
+	jin.RegisterInvoker(func(h func(*User, error) (int, *User)) inject.FastInvoker {
+		return jinInvoker2(h)
+	})
+	jin.RegisterInvoker(func(h func(*store)) inject.FastInvoker {
+		return jinInvoker3(h)
+	})
+}
+
+type jinInvoker0 func(context.Context) (*store, error)
+
+func (invoke jinInvoker0) Invoke(args []any) ([]reflect.Value, error) {
+	a0, _ := args[0].(context.Context)
+	r0, r1 := invoke(a0)
+	return []reflect.Value{reflect.ValueOf(&r0).Elem(), reflect.ValueOf(&r1).Elem()}, nil
+}
+
+type jinInvoker1 func(*http.Request) (*User, error)
+
+func (invoke jinInvoker1) Invoke(args []any) ([]reflect.Value, error) {
+	r0, r1 := invoke(args[0].(*http.Request))
+	return []reflect.Value{reflect.ValueOf(&r0).Elem(), reflect.ValueOf(&r1).Elem()}, nil
+}
+
+type jinInvoker2 func(*User, error) (int, *User)
+
+func (invoke jinInvoker2) Invoke(args []any) ([]reflect.Value, error) {
+	a1, _ := args[1].(error)
+	r0, r1 := invoke(args[0].(*User), a1)
+	return []reflect.Value{reflect.ValueOf(&r0).Elem(), reflect.ValueOf(&r1).Elem()}, nil
+}
+
+type jinInvoker3 func(*store)
+
+func (invoke jinInvoker3) Invoke(args []any) ([]reflect.Value, error) {
+	invoke(args[0].(*store))
+	return nil, nil
+}
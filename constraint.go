@@ -0,0 +1,127 @@
+package jin
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// paramConstraint restricts the values a :param segment matches, like the int of
+// :id<int>. It is either one of the named constraints or a regular expression
+// matching the whole segment.
+type paramConstraint struct {
+	// key is the name of the param, without the constraint.
+	key string
+	// expr is the constraint between the angle brackets.
+	expr  string
+	match func(string) bool
+}
+
+// Named constraints, they take precedence over the regular expressions of the same
+// text.
+const (
+	ConstraintInt  = "int"
+	ConstraintUUID = "uuid"
+)
+
+var namedConstraints = map[string]func(string) bool{
+	ConstraintInt:  isInt,
+	ConstraintUUID: isUUID,
+}
+
+// constraintMatchers caches the match functions of the constraints by expression,
+// so that the routes sharing one and Engine.URL don't compile it again.
+var constraintMatchers sync.Map
+
+// parseWildcard splits a :param wildcard into its key and constraint, which is nil
+// if it hasn't any. It panics if the constraint is malformed.
+func parseWildcard(wildcard, fullPath string) (string, *paramConstraint) {
+	key := wildcard[1:]
+	start := strings.IndexByte(key, '<')
+	if start < 0 {
+		return key, nil
+	}
+	if wildcard[0] != ':' {
+		panic("constraints are only allowed on :param wildcards, has: '" +
+			wildcard + "' in path '" + fullPath + "'")
+	}
+	if key[len(key)-1] != '>' {
+		panic("unterminated constraint in wildcard '" + wildcard + "' in path '" + fullPath + "'")
+	}
+	c := &paramConstraint{key: key[:start], expr: key[start+1 : len(key)-1]}
+	if c.key == "" {
+		panic("wildcards must be named with a non-empty name in path '" + fullPath + "'")
+	}
+	if c.expr == "" {
+		panic("empty constraint in wildcard '" + wildcard + "' in path '" + fullPath + "'")
+	}
+	if match, ok := constraintMatchers.Load(c.expr); ok {
+		c.match = match.(func(string) bool)
+		return c.key, c
+	}
+	match, ok := namedConstraints[c.expr]
+	if !ok {
+		re, err := regexp.Compile(`^(?:` + c.expr + `)$`)
+		if err != nil {
+			panic("invalid constraint in wildcard '" + wildcard + "' in path '" + fullPath + "': " + err.Error())
+		}
+		match = re.MatchString
+	}
+	constraintMatchers.Store(c.expr, match)
+	c.match = match
+	return c.key, c
+}
+
+func isInt(s string) bool {
+	// rule out the segments which aren't numbers first, as the error of Atoi allocates
+	digits := s
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		digits = s[1:]
+	}
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return false
+	}
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// isUUID reports whether s is a UUID in its canonical textual form, like
+// 123e4567-e89b-12d3-a456-426614174000.
+func isUUID(s string) bool {
+	_, err := parseUUID(s)
+	return err == nil
+}
+
+func parseUUID(s string) ([16]byte, error) {
+	var uuid [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return uuid, strconv.ErrSyntax
+	}
+	j := 0
+	for i := 0; i < len(s); i += 2 {
+		if s[i] == '-' {
+			i++
+		}
+		hi, ok1 := fromHexChar(s[i])
+		lo, ok2 := fromHexChar(s[i+1])
+		if !ok1 || !ok2 {
+			return uuid, strconv.ErrSyntax
+		}
+		uuid[j] = hi<<4 | lo
+		j++
+	}
+	return uuid, nil
+}
+
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
@@ -1,18 +1,42 @@
 package jin
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/juanjiTech/inject/v2"
 	"github.com/juanjiTech/jin/render"
 )
 
+// Content-Type MIME of the most common data formats.
+const (
+	MIMEJSON  = "application/json"
+	MIMEYAML  = "application/yaml"
+	MIMEYAML2 = "application/x-yaml"
+	MIMEPlain = "text/plain"
+)
+
+// ContextKey is the key that a Context returns itself for.
+const ContextKey = "_juanjiTech/jin/contextkey"
+
 // abortIndex represents a typical value used in abort functions.
 const abortIndex int8 = math.MaxInt8 >> 1
 
+// defaultSecureJSONPrefix is prepended by Context.SecureJSON to JSON arrays.
+const defaultSecureJSONPrefix = "while(1);"
+
+var _ context.Context = (*Context)(nil)
+
 type Context struct {
 	inject.Injector
 	writermem responseWriter
@@ -21,30 +45,139 @@ type Context struct {
 	Params    Params
 
 	// Errors is a list of errors attached to all the handlers/middlewares who used this context.
-	Errors []*error
+	Errors errorMsgs
+
+	// Keys is a key/value pair exclusively for the context of each request.
+	// Unlike the injector, values are keyed by name, so two values of the same type don't collide.
+	Keys map[string]any
+
+	// This mutex protects Keys map.
+	mu sync.RWMutex
 
 	handlers HandlersChain
 	fullPath string
+	route    *routeEntry
 	index    int8
+	engine   *Engine
+
+	// plans are the invocation plans of the handlers, see handlerPlan.
+	plans []handlerPlan
+	// args is reused to pass the arguments of the fast invokers.
+	args []any
 
 	params       *Params
 	skippedNodes *[]skippedNode
+
+	// produces is the list of content types the route produces, see Produces.
+	produces []string
+
+	// injectorDetached reports whether a copy of the context holds on to the Injector.
+	injectorDetached bool
+
+	// released reports whether ServeHTTP returned the context to the pool, see
+	// Engine.PanicOnReleasedContext.
+	released atomic.Bool
+
+	// queryCache caches the query result from c.Request.URL.Query().
+	queryCache url.Values
+
+	// formCache caches c.Request.PostForm, which contains the parsed form data from POST, PATCH,
+	// or PUT body parameters.
+	formCache url.Values
 }
 
 func (c *Context) reset() {
-	if c.Injector == nil {
-		c.Injector = inject.New()
+	if c.Injector == nil || c.injectorDetached {
+		// a copy still references the old injector, so don't wipe it out under it
+		c.Injector = newRequestInjector(c)
+		c.injectorDetached = false
 	}
 	c.Injector.Reset()
+	c.released.Store(false)
 	c.Writer = &c.writermem
 	c.Params = c.Params[:0]
 
 	c.Errors = c.Errors[:0]
+	c.Keys = nil
 
 	*c.params = (*c.params)[:0]
 	c.handlers = nil
 	c.index = -1
 	c.fullPath = ""
+	c.route = nil
+	c.plans = nil
+	clear(c.args[:cap(c.args)])
+	c.queryCache = nil
+	c.formCache = nil
+	c.produces = nil
+}
+
+// dispose closes the values built by scoped providers once the request is done.
+// They are left open until the copies of the context are released, see Copy.
+func (c *Context) dispose() {
+	if inj, ok := c.Injector.(*requestInjector); ok {
+		inj.release()
+	}
+}
+
+// Copy returns a copy of the current context that can be safely used outside the request's scope.
+// This has to be used when the context has to be passed to a goroutine.
+// The copy holds the request, params, full path, errors and keys, and resolves dependencies
+// through a child of the current injector, which sees the values mapped or built before
+// Copy is called but not the ones mapped afterwards. It is read-only: writing to its Writer fails
+// instead of corrupting the response of whatever request reuses the original context.
+//
+// The copy builds the request-scoped values the request hasn't built yet in a scope
+// of its own. Call Release once done with the copy: the values built for it are then
+// closed, and the ones built for the request are closed once the request and all
+// its copies are done.
+func (c *Context) Copy() *Context {
+	cp := Context{
+		Request:  c.Request,
+		fullPath: c.fullPath,
+		route:    c.route,
+		engine:   c.engine,
+		index:    abortIndex,
+	}
+	cp.Writer = newDetachedResponseWriter(c.Writer)
+
+	switch inj := c.Injector.(type) {
+	case *requestInjector:
+		cp.Injector = inj.child(&cp)
+		c.injectorDetached = true
+	case nil:
+		cp.Injector = inject.New()
+	default:
+		cp.Injector = inject.New().SetParent(c.Injector)
+		c.injectorDetached = true
+	}
+	cp.Map(cp.Writer, cp.Request, &cp)
+
+	cp.Params = make(Params, len(c.Params))
+	copy(cp.Params, c.Params)
+	cp.params = &cp.Params
+
+	cp.Errors = make(errorMsgs, len(c.Errors))
+	copy(cp.Errors, c.Errors)
+
+	c.mu.RLock()
+	if c.Keys != nil {
+		cp.Keys = make(map[string]any, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	c.mu.RUnlock()
+
+	return &cp
+}
+
+// Release closes the values built by the scoped providers for the copy c, see Copy.
+// It has no effect on a context which isn't a copy, nor when called again.
+func (c *Context) Release() {
+	if inj, ok := c.Injector.(*requestInjector); ok && inj.parent != nil {
+		inj.release()
+	}
 }
 
 // FullPath returns a matched route full path. For not found routes
@@ -57,29 +190,57 @@ func (c *Context) FullPath() string {
 	return c.fullPath
 }
 
+// Route returns the RouteInfo of the matched route, with its name, metadata and tags.
+// It is the zero RouteInfo if no route matched, for NoRoute and NoMethod handlers.
+func (c *Context) Route() RouteInfo {
+	if c.route == nil {
+		return RouteInfo{}
+	}
+	return c.route.info
+}
+
 // Next should be used only inside middleware.
 // It executes the pending handlers in the chain inside the calling handler.
 // See example in GitHub.
 func (c *Context) Next() {
 	c.index++
 	for c.index < int8(len(c.handlers)) {
-		h := c.handlers[c.index]
-		if h == nil {
+		plan := c.handlerPlan(c.index)
+		if plan.handler == nil {
 			c.index++
 			continue
 		}
-		values, err := c.Invoke(h)
+		values, err := c.invoke(plan)
 		if err != nil {
+			var providerErr *ProviderError
+			if errors.As(err, &providerErr) {
+				_ = c.Error(err)
+				c.Abort()
+				return
+			}
 			panic(fmt.Sprintf("unable to invoke the %s handler [%s:%T]: %v",
-				ordinalize(int(c.index)), nameOfFunction(h), h, err))
+				ordinalize(int(c.index)), nameOfFunction(plan.handler), plan.handler, err))
 		}
 		c.index++
-
-		fnType := reflect.TypeOf(h)
-		// ignore check fnType is nil or Func
+		if len(values) == 0 {
+			continue
+		}
 
 		for i, val := range values {
-			c.Set(fnType.Out(i), val)
+			c.Injector.Set(plan.out[i], val)
+		}
+
+		if !plan.passErrors && plan.returnsError {
+			if err, _ := values[len(values)-1].Interface().(error); err != nil {
+				_ = c.Error(err)
+				c.Abort()
+				return
+			}
+		}
+
+		// the return values of the final handler are the response
+		if c.index == int8(len(c.handlers)) {
+			c.renderResult(plan.fnType, values)
 		}
 	}
 }
@@ -97,18 +258,84 @@ func (c *Context) Abort() {
 	c.index = abortIndex
 }
 
+// AbortWithStatus calls `Abort()` and writes the headers with the specified status code.
+// For example, a failed attempt to authenticate a request could use: context.AbortWithStatus(401).
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Writer.WriteHeaderNow()
+	c.Abort()
+}
+
+// AbortWithStatusJSON calls `Abort()` and then `JSON` internally.
+// This method stops the chain, writes the status code and return a JSON body.
+// It also sets the Content-Type as "application/json".
+func (c *Context) AbortWithStatusJSON(code int, jsonObj any) {
+	c.Abort()
+	c.JSON(code, jsonObj)
+}
+
+// AbortWithError calls `AbortWithStatus()` and `Error()` internally.
+// This method stops the chain, writes the status code and pushes the specified error to `c.Errors`.
+// See Context.Error() for more details.
+func (c *Context) AbortWithError(code int, err error) *Error {
+	c.AbortWithStatus(code)
+	return c.Error(err)
+}
+
 // Error attaches an error to the current context. The error is pushed to a list of errors.
 // It's a good idea to call Error for each error that occurred during the resolution of a request.
 // A middleware can be used to collect all the errors and push them to a database together,
 // print a log, or append it in the HTTP response.
 // Error will panic if err is nil.
-func (c *Context) Error(err error) *error {
+func (c *Context) Error(err error) *Error {
 	if err == nil {
 		panic("err is nil")
 	}
 
-	c.Errors = append(c.Errors, &err)
-	return &err
+	var parsedError *Error
+	ok := errors.As(err, &parsedError)
+	if !ok {
+		parsedError = &Error{
+			Err:  err,
+			Type: ErrorTypePrivate,
+		}
+	}
+
+	c.Errors = append(c.Errors, parsedError)
+	return parsedError
+}
+
+// handleErrors lets the Engine.ErrorHandler render the response once the chain is
+// done, if errors were recorded and nothing has been written yet.
+func (c *Context) handleErrors() {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	errorHandler := DefaultErrorHandler
+	if c.engine != nil && c.engine.ErrorHandler != nil {
+		errorHandler = c.engine.ErrorHandler
+	}
+	errorHandler(c, c.Errors.Last())
+}
+
+// lookupError maps err with the error registries of the matched route's group and
+// its enclosing groups, see RouterGroup.Errors.
+func (c *Context) lookupError(err error) (code int, body any, ok bool) {
+	var group *RouterGroup
+	if c.route != nil {
+		group = c.route.group
+	} else if c.engine != nil {
+		group = &c.engine.RouterGroup
+	}
+	for ; group != nil; group = group.parent {
+		if group.errors == nil {
+			continue
+		}
+		if code, body, ok = group.errors.Lookup(err); ok {
+			return
+		}
+	}
+	return
 }
 
 // Status sets the HTTP response code.
@@ -141,7 +368,493 @@ func (c *Context) Render(code int, r render.Render) {
 
 	if err := r.Render(c.Writer); err != nil {
 		// Pushing error to c.Errors
-		_ = c.Error(err)
+		_ = c.Error(err).SetType(ErrorTypeRender)
 		c.Abort()
 	}
 }
+
+/************************************/
+/******** METADATA MANAGEMENT********/
+/************************************/
+
+// Set is used to store a new key/value pair exclusively for this context.
+// It also lazy initializes c.Keys if it was not used previously.
+func (c *Context) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]any)
+	}
+
+	c.Keys[key] = value
+}
+
+// Get returns the value for the given key, ie: (value, true).
+// If the value does not exist it returns (nil, false)
+func (c *Context) Get(key string) (value any, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet returns the value for the given key if it exists, otherwise it panics.
+func (c *Context) MustGet(key string) any {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("Key \"" + key + "\" does not exist")
+}
+
+// GetAs returns the value for the given key asserted to T, ie: (value, true).
+// If the value does not exist or is not a T it returns the zero value of T and false.
+func GetAs[T any](c *Context, key string) (value T, ok bool) {
+	v, exists := c.Get(key)
+	if !exists {
+		return
+	}
+	value, ok = v.(T)
+	return
+}
+
+// MustGetAs returns the value for the given key asserted to T, otherwise it panics.
+func MustGetAs[T any](c *Context, key string) T {
+	return c.MustGet(key).(T)
+}
+
+/************************************/
+/******** RESPONSE RENDERING ********/
+/************************************/
+
+// IndentedJSON serializes the given struct as pretty JSON (indented + endlines) into the response body.
+// It also sets the Content-Type as "application/json".
+// WARNING: we recommend using this only for development purposes since printing pretty JSON is
+// more CPU and bandwidth consuming. Use Context.JSON() instead.
+func (c *Context) IndentedJSON(code int, obj any) {
+	c.Render(code, render.IndentedJSON{Data: obj})
+}
+
+// SecureJSON serializes the given struct as Secure JSON into the response body.
+// Default prepends "while(1)," to response body if the given struct is array values.
+// It also sets the Content-Type as "application/json".
+func (c *Context) SecureJSON(code int, obj any) {
+	c.Render(code, render.SecureJSON{Prefix: defaultSecureJSONPrefix, Data: obj})
+}
+
+// JSONP serializes the given struct as JSON into the response body.
+// It adds padding to response body to request data from a server residing in a different domain than the client.
+// It also sets the Content-Type as "application/javascript".
+func (c *Context) JSONP(code int, obj any) {
+	callback := c.DefaultQuery("callback", "")
+	if callback == "" {
+		c.Render(code, render.JSON{Data: obj})
+		return
+	}
+	c.Render(code, render.JsonpJSON{Callback: callback, Data: obj})
+}
+
+// JSON serializes the given struct as JSON into the response body.
+// It also sets the Content-Type as "application/json".
+func (c *Context) JSON(code int, obj any) {
+	c.Render(code, render.JSON{Data: obj})
+}
+
+// AsciiJSON serializes the given struct as JSON into the response body with unicode to ASCII string.
+// It also sets the Content-Type as "application/json".
+func (c *Context) AsciiJSON(code int, obj any) {
+	c.Render(code, render.AsciiJSON{Data: obj})
+}
+
+// PureJSON serializes the given struct as JSON into the response body.
+// PureJSON, unlike JSON, does not replace special html characters with their unicode entities.
+func (c *Context) PureJSON(code int, obj any) {
+	c.Render(code, render.PureJSON{Data: obj})
+}
+
+// YAML serializes the given struct as YAML into the response body.
+func (c *Context) YAML(code int, obj any) {
+	c.Render(code, render.YAML{Data: obj})
+}
+
+// String writes the given string into the response body.
+func (c *Context) String(code int, format string, values ...any) {
+	c.Render(code, render.String{Format: format, Data: values})
+}
+
+// Redirect returns an HTTP redirect to the specific location.
+func (c *Context) Redirect(code int, location string) {
+	c.Render(-1, render.Redirect{
+		Code:     code,
+		Location: location,
+		Request:  c.Request,
+	})
+}
+
+// Data writes some data into the body stream and updates the HTTP code.
+func (c *Context) Data(code int, contentType string, data []byte) {
+	c.Render(code, render.Data{
+		ContentType: contentType,
+		Data:        data,
+	})
+}
+
+// NegotiateFormat returns an acceptable Accept format.
+// The first offer is returned if the request has no Accept header, and an
+// empty string if none of the offers is acceptable.
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		panic("you must provide at least one offer")
+	}
+
+	accepted := parseAccept(c.GetHeader("Accept"))
+	if len(accepted) == 0 {
+		return offered[0]
+	}
+	for _, accept := range accepted {
+		for _, offer := range offered {
+			// According to RFC 2616 and RFC 2396, non-ASCII characters are not allowed in headers,
+			// therefore we can just iterate over the string without casting it into []rune
+			i := 0
+			for ; i < len(accept) && i < len(offer); i++ {
+				if accept[i] == '*' || offer[i] == '*' {
+					return offer
+				}
+				if accept[i] != offer[i] {
+					break
+				}
+			}
+			if i == len(accept) && i == len(offer) {
+				return offer
+			}
+		}
+	}
+	return ""
+}
+
+/************************************/
+/************ INPUT DATA ************/
+/************************************/
+
+// Param returns the value of the URL param.
+// It is a shortcut for c.Params.ByName(key)
+//
+//	router.GET("/user/:id", func(c *jin.Context) {
+//	    // a GET request to /user/john
+//	    id := c.Param("id") // id == "john"
+//	    // a GET request to /user/john/
+//	    id := c.Param("id") // id == "/john/"
+//	})
+func (c *Context) Param(key string) string {
+	return c.Params.ByName(key)
+}
+
+// Query returns the keyed url query value if it exists,
+// otherwise it returns an empty string `("")`.
+// It is shortcut for `c.Request.URL.Query().Get(key)`
+//
+//	    GET /path?id=1234&name=Manu&value=
+//		   c.Query("id") == "1234"
+//		   c.Query("name") == "Manu"
+//		   c.Query("value") == ""
+//		   c.Query("wtf") == ""
+func (c *Context) Query(key string) (value string) {
+	value, _ = c.GetQuery(key)
+	return
+}
+
+// DefaultQuery returns the keyed url query value if it exists,
+// otherwise it returns the specified defaultValue string.
+// See: Query() and GetQuery() for further information.
+//
+//	GET /?name=Manu&lastname=
+//	c.DefaultQuery("name", "unknown") == "Manu"
+//	c.DefaultQuery("id", "none") == "none"
+//	c.DefaultQuery("lastname", "none") == ""
+func (c *Context) DefaultQuery(key, defaultValue string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetQuery is like Query(), it returns the keyed url query value
+// if it exists `(value, true)` (even when the value is an empty string),
+// otherwise it returns `("", false)`.
+// It is shortcut for `c.Request.URL.Query().Get(key)`
+//
+//	GET /?name=Manu&lastname=
+//	("Manu", true) == c.GetQuery("name")
+//	("", false) == c.GetQuery("id")
+//	("", true) == c.GetQuery("lastname")
+func (c *Context) GetQuery(key string) (string, bool) {
+	if values, ok := c.GetQueryArray(key); ok {
+		return values[0], ok
+	}
+	return "", false
+}
+
+// QueryArray returns a slice of strings for a given query key.
+// The length of the slice depends on the number of params with the given key.
+func (c *Context) QueryArray(key string) (values []string) {
+	values, _ = c.GetQueryArray(key)
+	return
+}
+
+func (c *Context) initQueryCache() {
+	if c.queryCache == nil {
+		if c.Request != nil && c.Request.URL != nil {
+			c.queryCache = c.Request.URL.Query()
+		} else {
+			c.queryCache = url.Values{}
+		}
+	}
+}
+
+// GetQueryArray returns a slice of strings for a given query key, plus
+// a boolean value whether at least one value exists for the given key.
+func (c *Context) GetQueryArray(key string) (values []string, ok bool) {
+	c.initQueryCache()
+	values, ok = c.queryCache[key]
+	return
+}
+
+// QueryMap returns a map for a given query key.
+func (c *Context) QueryMap(key string) (dicts map[string]string) {
+	dicts, _ = c.GetQueryMap(key)
+	return
+}
+
+// GetQueryMap returns a map for a given query key, plus a boolean value
+// whether at least one value exists for the given key.
+func (c *Context) GetQueryMap(key string) (map[string]string, bool) {
+	c.initQueryCache()
+	return c.get(c.queryCache, key)
+}
+
+// PostForm returns the specified key from a POST urlencoded form or multipart form
+// when it exists, otherwise it returns an empty string `("")`.
+func (c *Context) PostForm(key string) (value string) {
+	value, _ = c.GetPostForm(key)
+	return
+}
+
+// DefaultPostForm returns the specified key from a POST urlencoded form or multipart form
+// when it exists, otherwise it returns the specified defaultValue string.
+// See: PostForm() and GetPostForm() for further information.
+func (c *Context) DefaultPostForm(key, defaultValue string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetPostForm is like PostForm(key). It returns the specified key from a POST urlencoded
+// form or multipart form when it exists `(value, true)` (even when the value is an empty string),
+// otherwise it returns ("", false).
+// For example, during a PATCH request to update the user's email:
+//
+//	    email=mail@example.com  -->  ("mail@example.com", true) := GetPostForm("email") // set email to "mail@example.com"
+//		   email=                  -->  ("", true) := GetPostForm("email") // set email to ""
+//	                            -->  ("", false) := GetPostForm("email") // do nothing with email
+func (c *Context) GetPostForm(key string) (string, bool) {
+	if values, ok := c.GetPostFormArray(key); ok {
+		return values[0], ok
+	}
+	return "", false
+}
+
+// PostFormArray returns a slice of strings for a given form key.
+// The length of the slice depends on the number of params with the given key.
+func (c *Context) PostFormArray(key string) (values []string) {
+	values, _ = c.GetPostFormArray(key)
+	return
+}
+
+func (c *Context) initFormCache() {
+	if c.formCache == nil {
+		req := c.Request
+		if err := req.ParseMultipartForm(c.maxMultipartMemory()); err != nil {
+			if !errors.Is(err, http.ErrNotMultipart) {
+				debugPrint("error on parse multipart form array: %v", err)
+			}
+		}
+		c.formCache = req.PostForm
+		if c.formCache == nil {
+			c.formCache = make(url.Values)
+		}
+	}
+}
+
+// GetPostFormArray returns a slice of strings for a given form key, plus
+// a boolean value whether at least one value exists for the given key.
+func (c *Context) GetPostFormArray(key string) (values []string, ok bool) {
+	c.initFormCache()
+	values, ok = c.formCache[key]
+	return
+}
+
+// PostFormMap returns a map for a given form key.
+func (c *Context) PostFormMap(key string) (dicts map[string]string) {
+	dicts, _ = c.GetPostFormMap(key)
+	return
+}
+
+// GetPostFormMap returns a map for a given form key, plus a boolean value
+// whether at least one value exists for the given key.
+func (c *Context) GetPostFormMap(key string) (map[string]string, bool) {
+	c.initFormCache()
+	return c.get(c.formCache, key)
+}
+
+// get is an internal method and returns a map which satisfies conditions.
+func (c *Context) get(m map[string][]string, key string) (map[string]string, bool) {
+	dicts := make(map[string]string)
+	exist := false
+	for k, v := range m {
+		if i := strings.IndexByte(k, '['); i >= 1 && k[0:i] == key {
+			if j := strings.IndexByte(k[i+1:], ']'); j >= 1 {
+				exist = true
+				dicts[k[i+1:][:j]] = v[0]
+			}
+		}
+	}
+	return dicts, exist
+}
+
+// FormFile returns the first file for the provided form key.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.Request.MultipartForm == nil {
+		if err := c.Request.ParseMultipartForm(c.maxMultipartMemory()); err != nil {
+			return nil, err
+		}
+	}
+	f, fh, err := c.Request.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+	return fh, err
+}
+
+// MultipartForm is the parsed multipart form, including file uploads.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	err := c.Request.ParseMultipartForm(c.maxMultipartMemory())
+	return c.Request.MultipartForm, err
+}
+
+func (c *Context) maxMultipartMemory() int64 {
+	if c.engine == nil {
+		return defaultMultipartMemory
+	}
+	return c.engine.MaxMultipartMemory
+}
+
+// GetHeader returns value from request headers.
+func (c *Context) GetHeader(key string) string {
+	return c.Request.Header.Get(key)
+}
+
+// Cookie returns the named cookie provided in the request or
+// ErrNoCookie if not found. And return the named cookie is unescaped.
+// If multiple cookies match the given name, only one cookie will
+// be returned.
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	val, _ := url.QueryUnescape(cookie.Value)
+	return val, nil
+}
+
+/************************************/
+/***** GOLANG.ORG/X/NET/CONTEXT *****/
+/************************************/
+
+// closedChan is the Done channel of a released context.
+var closedChan = make(chan struct{})
+
+func init() {
+	close(closedChan)
+}
+
+// hasRequestContext returns whether c.Request has Context.
+func (c *Context) hasRequestContext() bool {
+	return c.Request != nil && c.Request.Context() != nil
+}
+
+// isReleased returns whether c was returned to the pool by ServeHTTP, in which case
+// it behaves like a canceled context, or panics if Engine.PanicOnReleasedContext is set.
+func (c *Context) isReleased() bool {
+	if !c.released.Load() {
+		return false
+	}
+	if c.engine != nil && c.engine.PanicOnReleasedContext {
+		panic("jin: the Context is used after its request was handled, use Context.Copy to retain it")
+	}
+	return true
+}
+
+// Deadline returns the deadline of c.Request.Context(), or that there is no deadline
+// (ok==false) when c.Request has no Context or c was released.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.isReleased() || !c.hasRequestContext() {
+		return
+	}
+	return c.Request.Context().Deadline()
+}
+
+// Done returns the Done channel of c.Request.Context(), nil (chan which will wait
+// forever) when c.Request has no Context, or a closed channel when c was released.
+func (c *Context) Done() <-chan struct{} {
+	if c.isReleased() {
+		return closedChan
+	}
+	if !c.hasRequestContext() {
+		return nil
+	}
+	return c.Request.Context().Done()
+}
+
+// Err returns the error of c.Request.Context(), nil when c.Request has no Context,
+// or context.Canceled when c was released.
+func (c *Context) Err() error {
+	if c.isReleased() {
+		return context.Canceled
+	}
+	if !c.hasRequestContext() {
+		return nil
+	}
+	return c.Request.Context().Err()
+}
+
+// Value returns the value associated with this context for key, or nil
+// if no value is associated with key or c was released. A string key is looked
+// up in c.Keys, a reflect.Type key in the injector, and anything else (or a miss)
+// falls back to c.Request.Context().
+// Note that Value shadows inject.TypeMapper.Value, use c.Injector.Value for
+// the reflect.Value of a mapped type.
+func (c *Context) Value(key any) any {
+	if c.isReleased() {
+		return nil
+	}
+	switch k := key.(type) {
+	case string:
+		if k == ContextKey {
+			return c
+		}
+		if val, exists := c.Get(k); exists {
+			return val
+		}
+	case reflect.Type:
+		if c.Injector != nil {
+			if val := c.Injector.Value(k); val.IsValid() {
+				return val.Interface()
+			}
+		}
+	}
+	if !c.hasRequestContext() {
+		return nil
+	}
+	return c.Request.Context().Value(key)
+}
@@ -1,11 +1,19 @@
 package jin
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/juanjiTech/jin/render"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +22,7 @@ import (
 // CreateTestContext returns a fresh engine and context for testing purposes
 func CreateTestContext(w http.ResponseWriter) (c *Context, r *Engine) {
 	r = New()
-	c = r.allocateContext(0)
+	c = r.allocateContext(r.table())
 	c.reset()
 	c.writermem.reset(w)
 	return
@@ -22,7 +30,7 @@ func CreateTestContext(w http.ResponseWriter) (c *Context, r *Engine) {
 
 // CreateTestContextOnly returns a fresh context base on the engine for testing purposes
 func CreateTestContextOnly(w http.ResponseWriter, r *Engine) (c *Context) {
-	c = r.allocateContext(r.maxParams)
+	c = r.allocateContext(r.table())
 	c.reset()
 	c.writermem.reset(w)
 	return
@@ -71,7 +79,13 @@ func TestContextError(t *testing.T) {
 	err := errors.New("test error")
 	c.Error(err)
 	assert.Len(t, c.Errors, 1)
-	assert.Equal(t, "test error", (*c.Errors[0]).Error())
+	assert.Equal(t, "test error", c.Errors[0].Error())
+	assert.Equal(t, ErrorTypePrivate, c.Errors[0].Type)
+
+	// a *Error is recorded as is
+	c.Error(&Error{Err: errors.New("public"), Type: ErrorTypePublic})
+	assert.Len(t, c.Errors, 2)
+	assert.Equal(t, ErrorTypePublic, c.Errors.Last().Type)
 
 	assert.Panics(t, func() {
 		c.Error(nil)
@@ -122,6 +136,7 @@ func TestContextRender(t *testing.T) {
 	c.Render(http.StatusOK, &render.YAML{Data: make(chan int)})
 	assert.True(t, c.IsAborted())
 	assert.NotEmpty(t, c.Errors)
+	assert.True(t, c.Errors.Last().IsType(ErrorTypeRender))
 }
 
 func TestContextNext(t *testing.T) {
@@ -134,3 +149,569 @@ func TestContextNext(t *testing.T) {
 	c.Next()
 	assert.Equal(t, http.StatusOK, recorder.Code)
 }
+
+func TestContextParam(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Params = Params{
+		Param{Key: "first", Value: "jin"},
+		Param{Key: "last", Value: "framework"},
+	}
+	assert.Equal(t, "jin", c.Param("first"))
+	assert.Equal(t, "framework", c.Param("last"))
+	assert.Empty(t, c.Param("missing"))
+}
+
+func TestContextQuery(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/?foo=bar&page=10&id=&both=1&both=2&ids[a]=hi&ids[b]=3.14", nil)
+
+	value, ok := c.GetQuery("foo")
+	assert.True(t, ok)
+	assert.Equal(t, "bar", value)
+	assert.Equal(t, "bar", c.DefaultQuery("foo", "none"))
+	assert.Equal(t, "bar", c.Query("foo"))
+
+	value, ok = c.GetQuery("id")
+	assert.True(t, ok)
+	assert.Empty(t, value)
+	assert.Empty(t, c.DefaultQuery("id", "nada"))
+
+	value, ok = c.GetQuery("NoKey")
+	assert.False(t, ok)
+	assert.Empty(t, value)
+	assert.Equal(t, "nada", c.DefaultQuery("NoKey", "nada"))
+
+	assert.Equal(t, []string{"1", "2"}, c.QueryArray("both"))
+	assert.Empty(t, c.QueryArray("nokey"))
+	assert.Equal(t, map[string]string{"a": "hi", "b": "3.14"}, c.QueryMap("ids"))
+
+	dicts, ok := c.GetQueryMap("nokey")
+	assert.False(t, ok)
+	assert.Empty(t, dicts)
+
+	// the cache must be dropped by reset so pooled contexts don't leak queries
+	assert.NotNil(t, c.queryCache)
+	c.reset()
+	assert.Nil(t, c.queryCache)
+}
+
+func TestContextPostForm(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	body := strings.NewReader("foo=bar&page=11&both=&foo=second&names[a]=thinkerou&names[b]=tianou")
+	c.Request, _ = http.NewRequest("POST", "/?both=GET&id=main", body)
+	c.Request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	assert.Equal(t, "bar", c.PostForm("foo"))
+	assert.Equal(t, "11", c.DefaultPostForm("page", "0"))
+	assert.Equal(t, "nothing", c.DefaultPostForm("NoKey", "nothing"))
+
+	value, ok := c.GetPostForm("both")
+	assert.True(t, ok)
+	assert.Empty(t, value)
+	assert.Equal(t, "GET", c.Query("both"))
+
+	_, ok = c.GetPostForm("id")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"bar", "second"}, c.PostFormArray("foo"))
+	assert.Equal(t, map[string]string{"a": "thinkerou", "b": "tianou"}, c.PostFormMap("names"))
+
+	c.reset()
+	assert.Nil(t, c.formCache)
+}
+
+func TestContextMultipartForm(t *testing.T) {
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	assert.NoError(t, mw.WriteField("foo", "bar"))
+	w, err := mw.CreateFormFile("file", "test")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("test"))
+	assert.NoError(t, err)
+	mw.Close()
+
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/", buf)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+
+	f, err := c.FormFile("file")
+	assert.NoError(t, err)
+	assert.Equal(t, "test", f.Filename)
+
+	form, err := c.MultipartForm()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bar"}, form.Value["foo"])
+	assert.Equal(t, "bar", c.PostForm("foo"))
+
+	_, err = c.FormFile("missing")
+	assert.Error(t, err)
+}
+
+func TestContextGetHeaderAndCookie(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.Header.Set("X-Request-Id", "abc")
+	c.Request.AddCookie(&http.Cookie{Name: "user", Value: "jin%20user"})
+
+	assert.Equal(t, "abc", c.GetHeader("X-Request-Id"))
+	assert.Empty(t, c.GetHeader("X-Missing"))
+
+	cookie, err := c.Cookie("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "jin user", cookie)
+
+	_, err = c.Cookie("missing")
+	assert.ErrorIs(t, err, http.ErrNoCookie)
+}
+
+func TestContextRenderJSONHelpers(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.JSON(http.StatusCreated, H{"foo": "bar", "html": "<b>"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "{\"foo\":\"bar\",\"html\":\"\\u003cb\\u003e\"}", w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.IndentedJSON(http.StatusOK, H{"foo": "bar"})
+	assert.Equal(t, "{\n    \"foo\": \"bar\"\n}", w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.SecureJSON(http.StatusOK, []string{"foo"})
+	assert.Equal(t, "while(1);[\"foo\"]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.AsciiJSON(http.StatusOK, H{"lang": "GO语言"})
+	assert.Equal(t, "{\"lang\":\"GO\\u8bed\\u8a00\"}", w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.PureJSON(http.StatusOK, H{"html": "<b>"})
+	assert.Equal(t, "{\"html\":\"<b>\"}\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?callback=x", nil)
+	c.JSONP(http.StatusOK, H{"foo": "bar"})
+	assert.Equal(t, "x({\"foo\":\"bar\"});", w.Body.String())
+	assert.Equal(t, "application/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+
+	// JSONP without a callback falls back to plain JSON
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.JSONP(http.StatusOK, H{"foo": "bar"})
+	assert.Equal(t, "{\"foo\":\"bar\"}", w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestContextRenderNoContentJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.JSON(http.StatusNoContent, H{"foo": "bar"})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestContextRenderYAMLStringData(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.YAML(http.StatusOK, H{"foo": "bar"})
+	assert.Equal(t, "foo: bar\n", w.Body.String())
+	assert.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.String(http.StatusOK, "test %s %d", "string", 2)
+	assert.Equal(t, "test string 2", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.Data(http.StatusOK, "text/csv", []byte(`foo,bar`))
+	assert.Equal(t, "foo,bar", w.Body.String())
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+
+	// render errors end up in c.Errors
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.JSON(http.StatusOK, make(chan int))
+	assert.True(t, c.IsAborted())
+	assert.Len(t, c.Errors, 1)
+}
+
+func TestContextRedirect(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "http://example.com", nil)
+	c.Redirect(http.StatusMovedPermanently, "http://google.com")
+	c.Writer.WriteHeaderNow()
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "http://google.com", w.Header().Get("Location"))
+
+	assert.Panics(t, func() { c.Redirect(http.StatusOK, "http://google.com") })
+}
+
+func TestContextAbortWithStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.index = 4
+	c.AbortWithStatus(http.StatusUnauthorized)
+	assert.Equal(t, abortIndex, c.index)
+	assert.Equal(t, http.StatusUnauthorized, c.Writer.Status())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, c.IsAborted())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, H{"foo": "fooValue"})
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Equal(t, "{\"foo\":\"fooValue\"}", w.Body.String())
+
+	w = httptest.NewRecorder()
+	c, _ = CreateTestContext(w)
+	err := c.AbortWithError(http.StatusUnauthorized, errors.New("bad input"))
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "bad input", err.Error())
+	assert.Len(t, c.Errors, 1)
+}
+
+func TestContextSetGet(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	assert.Nil(t, c.Keys)
+
+	c.Set("foo", "bar")
+	value, exists := c.Get("foo")
+	assert.Equal(t, "bar", value)
+	assert.True(t, exists)
+
+	value, exists = c.Get("foo2")
+	assert.Nil(t, value)
+	assert.False(t, exists)
+
+	assert.Equal(t, "bar", c.MustGet("foo"))
+	assert.Panics(t, func() { c.MustGet("no_exist") })
+
+	// values of the same type no longer collide
+	c.Set("user", "alice")
+	c.Set("tenant", "acme")
+	assert.Equal(t, "alice", c.MustGet("user"))
+	assert.Equal(t, "acme", c.MustGet("tenant"))
+
+	c.reset()
+	assert.Nil(t, c.Keys)
+}
+
+func TestContextGetAs(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Set("id", 42)
+	c.Set("flags", []string{"beta"})
+
+	id, ok := GetAs[int](c, "id")
+	assert.True(t, ok)
+	assert.Equal(t, 42, id)
+
+	name, ok := GetAs[string](c, "id")
+	assert.False(t, ok)
+	assert.Empty(t, name)
+
+	_, ok = GetAs[int](c, "missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"beta"}, MustGetAs[[]string](c, "flags"))
+	assert.Panics(t, func() { MustGetAs[string](c, "id") })
+}
+
+func TestContextSetGetConcurrent(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := strconv.Itoa(i)
+			c.Set(key, i)
+			_, _ = c.Get(key)
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, c.Keys, 10)
+}
+
+type contextKey string
+
+func TestContextImplementsContext(t *testing.T) {
+	var _ context.Context = &Context{}
+
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	_, ok := c.Deadline()
+	assert.False(t, ok)
+	assert.Nil(t, c.Done())
+	assert.Nil(t, c.Err())
+	assert.Nil(t, c.Value(contextKey("req")))
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), contextKey("req"), "value"), time.Minute)
+	defer cancel()
+	c.Request, _ = http.NewRequestWithContext(ctx, "GET", "/", nil)
+	deadline, ok := c.Deadline()
+	assert.True(t, ok)
+	want, _ := ctx.Deadline()
+	assert.Equal(t, want, deadline)
+	assert.Equal(t, ctx.Done(), c.Done())
+	assert.Equal(t, "value", c.Value(contextKey("req")))
+
+	cancel()
+	assert.ErrorIs(t, c.Err(), context.Canceled)
+}
+
+func TestContextReleased(t *testing.T) {
+	router := New()
+	var retained *Context
+	router.GET("/set", func(c *Context) {
+		c.Set("key", "value")
+		retained = c
+		assert.NoError(t, c.Err())
+		assert.Equal(t, "value", c.Value("key"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/set", nil))
+
+	// a retained context behaves like a canceled one once returned to the pool
+	_, ok := retained.Deadline()
+	assert.False(t, ok)
+	assert.ErrorIs(t, retained.Err(), context.Canceled)
+	assert.Nil(t, retained.Value("key"))
+	select {
+	case <-retained.Done():
+	default:
+		t.Error("the Done channel of a released context is not closed")
+	}
+
+	router.PanicOnReleasedContext = true
+	assert.Panics(t, func() { retained.Value("key") })
+	assert.Panics(t, func() { retained.Done() })
+
+	// a copy isn't pooled
+	router.GET("/copy", func(c *Context) {
+		c.Set("key", "value")
+		retained = c.Copy()
+	})
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/copy", nil))
+	assert.Equal(t, "value", retained.Value("key"))
+	assert.NoError(t, retained.Err())
+}
+
+func TestContextValue(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequestWithContext(
+		context.WithValue(context.Background(), "key", "from request"), "GET", "/", nil)
+
+	assert.Equal(t, c, c.Value(ContextKey))
+
+	// the request context is the last resort
+	assert.Equal(t, "from request", c.Value("key"))
+
+	c.Set("key", "from keys")
+	assert.Equal(t, "from keys", c.Value("key"))
+
+	c.Map(42)
+	assert.Equal(t, 42, c.Value(reflect.TypeOf(0)))
+	assert.Nil(t, c.Value(reflect.TypeOf(int64(0))))
+	assert.Nil(t, c.Value(1))
+}
+
+func TestContextCopy(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := CreateTestContext(w)
+	c.index = 2
+	c.Request, _ = http.NewRequest("POST", "/hola", nil)
+	c.handlers = HandlersChain{func(c *Context) {}}
+	c.Params = Params{Param{Key: "foo", Value: "bar"}}
+	c.fullPath = "/hola"
+	c.Set("foo", "bar")
+	c.Map("injected")
+	_ = c.Error(errors.New("oops"))
+	c.Writer.Header().Set("X-Original", "1")
+
+	cp := c.Copy()
+	assert.Nil(t, cp.handlers)
+	assert.Equal(t, abortIndex, cp.index)
+	assert.Equal(t, c.Request, cp.Request)
+	assert.Equal(t, c.Params, cp.Params)
+	assert.Equal(t, c.Keys, cp.Keys)
+	assert.Equal(t, c.Errors, cp.Errors)
+	assert.Equal(t, "/hola", cp.FullPath())
+	assert.Equal(t, "1", cp.Writer.Header().Get("X-Original"))
+
+	// the snapshot is detached from later changes of the original
+	c.Params[0].Value = "changed"
+	c.Set("foo", "changed")
+	assert.Equal(t, "bar", cp.Param("foo"))
+	assert.Equal(t, "bar", cp.MustGet("foo"))
+
+	// dependencies resolve through a child injector that survives the reuse of the original
+	c.reset()
+	_, err := cp.Invoke(func(s string, c *Context, r *http.Request) {
+		assert.Equal(t, "injected", s)
+		assert.Equal(t, cp, c)
+		assert.Equal(t, cp.Request, r)
+	})
+	assert.NoError(t, err)
+	assert.False(t, c.Injector.Value(reflect.TypeOf("")).IsValid())
+
+	// writing through the copy fails and doesn't reach the original response
+	n, err := cp.Writer.Write([]byte("hello"))
+	assert.ErrorIs(t, err, ErrDetachedWriter)
+	assert.Zero(t, n)
+	_, err = cp.Writer.WriteString("hello")
+	assert.ErrorIs(t, err, ErrDetachedWriter)
+	_, _, err = cp.Writer.Hijack()
+	assert.ErrorIs(t, err, ErrDetachedWriter)
+	cp.Writer.WriteHeader(http.StatusTeapot)
+	cp.Writer.WriteHeaderNow()
+	cp.Writer.Flush()
+	assert.Nil(t, cp.Writer.Pusher())
+	assert.Equal(t, http.StatusOK, cp.Writer.Status())
+	assert.False(t, cp.Writer.Written())
+	assert.Equal(t, noWritten, cp.Writer.Size())
+	assert.Empty(t, w.Body.String())
+
+	cp.JSON(http.StatusOK, H{"foo": "bar"})
+	assert.Empty(t, w.Body.String())
+	assert.Len(t, cp.Errors, 2)
+}
+
+func TestContextCopyConcurrentInjection(t *testing.T) {
+	router := New()
+	stringerType := reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	started, done := make(chan struct{}), make(chan struct{})
+	router.GET("/", func(c *Context) {
+		c.Map("before")
+		cp := c.Copy()
+		go func() {
+			defer close(done)
+			defer cp.Release()
+			close(started)
+			for i := 0; i < 1000; i++ {
+				// not mapped, so looked up among the implementations of the values
+				assert.Nil(t, cp.Value(stringerType))
+				assert.Equal(t, "before", cp.Value(reflect.TypeOf("")))
+			}
+		}()
+		<-started
+		for i := 0; i < 1000; i++ {
+			c.Map(i, strconv.Itoa(i))
+		}
+		<-done
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestContextNegotiateFormat(t *testing.T) {
+	c, _ := CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/", nil)
+
+	assert.Panics(t, func() { c.NegotiateFormat() })
+	assert.Equal(t, MIMEJSON, c.NegotiateFormat(MIMEJSON, MIMEYAML))
+
+	c.Request.Header.Add("Accept", "text/html , application/yaml;q=0.9, application/json;q=0.8")
+	assert.Equal(t, MIMEYAML, c.NegotiateFormat(MIMEJSON, MIMEYAML))
+	assert.Equal(t, MIMEJSON, c.NegotiateFormat(MIMEJSON))
+	assert.Empty(t, c.NegotiateFormat(MIMEPlain))
+
+	c.Request.Header.Set("Accept", "*/*")
+	assert.Equal(t, MIMEPlain, c.NegotiateFormat(MIMEPlain, MIMEJSON))
+
+	c.Request.Header.Set("Accept", "application/json;q=0, text/*")
+	assert.Equal(t, MIMEPlain, c.NegotiateFormat(MIMEJSON, MIMEPlain))
+}
+
+func TestContextNextErrorAbortsChain(t *testing.T) {
+	engine := New()
+	var after bool
+	engine.GET("/", func() error {
+		return errors.New("middleware failed")
+	}, func(c *Context) {
+		after = true
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.False(t, after)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestContextNextErrorHandler(t *testing.T) {
+	engine := New()
+	var handled errorMsgs
+	engine.ErrorHandler = func(c *Context, err error) {
+		handled = c.Errors
+		c.JSON(http.StatusBadRequest, H{"error": err.Error()})
+	}
+	engine.GET("/", func(c *Context) {
+		c.Next()
+		c.Writer.Header().Set("X-After", "1")
+	}, func() (string, error) {
+		return "", errors.New("bad request")
+	}, func(c *Context) {
+		t.Error("the chain should have been aborted")
+	})
+	engine.GET("/nil", func() error {
+		return nil
+	}, func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	engine.GET("/written", func(c *Context) error {
+		c.String(http.StatusAccepted, "partial")
+		return errors.New("too late")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, `{"error":"bad request"}`, w.Body.String())
+	assert.Len(t, handled, 1)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/nil", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+
+	// the error handler doesn't run once the response is written
+	handled = nil
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/written", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+	assert.Nil(t, handled)
+}
+
+func TestContextNextPassErrors(t *testing.T) {
+	engine := New()
+	engine.GET("/", PassErrors(func() (string, error) {
+		return "", errors.New("kept")
+	}), func(c *Context, err error) {
+		c.String(http.StatusOK, "got "+err.Error())
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "got kept", w.Body.String())
+}
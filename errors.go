@@ -1 +1,259 @@
 package jin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ErrorType is an unsigned 64-bit error code, the types can be combined as flags.
+type ErrorType uint64
+
+const (
+	// ErrorTypeBind is used when a binding middleware fails.
+	ErrorTypeBind ErrorType = 1 << 63
+	// ErrorTypeRender is used when Context.Render() fails.
+	ErrorTypeRender ErrorType = 1 << 62
+	// ErrorTypePrivate indicates a private error.
+	ErrorTypePrivate ErrorType = 1 << 0
+	// ErrorTypePublic indicates a public error.
+	ErrorTypePublic ErrorType = 1 << 1
+	// ErrorTypeAny indicates any other error.
+	ErrorTypeAny ErrorType = 1<<64 - 1
+)
+
+// Error represents an error recorded on a Context, see Context.Error.
+type Error struct {
+	Err  error
+	Type ErrorType
+	Meta any
+}
+
+type errorMsgs []*Error
+
+var _ error = (*Error)(nil)
+
+// SetType sets the error's type.
+func (msg *Error) SetType(flags ErrorType) *Error {
+	msg.Type = flags
+	return msg
+}
+
+// SetMeta sets the error's meta data.
+func (msg *Error) SetMeta(data any) *Error {
+	msg.Meta = data
+	return msg
+}
+
+// JSON creates a properly formatted JSON
+func (msg *Error) JSON() any {
+	jsonData := H{}
+	if msg.Meta != nil {
+		value := reflect.ValueOf(msg.Meta)
+		switch value.Kind() {
+		case reflect.Struct:
+			return msg.Meta
+		case reflect.Map:
+			for _, key := range value.MapKeys() {
+				jsonData[key.String()] = value.MapIndex(key).Interface()
+			}
+		default:
+			jsonData["meta"] = msg.Meta
+		}
+	}
+	if _, ok := jsonData["error"]; !ok {
+		jsonData["error"] = msg.Error()
+	}
+	return jsonData
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (msg *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(msg.JSON())
+}
+
+// Error implements the error interface.
+func (msg Error) Error() string {
+	return msg.Err.Error()
+}
+
+// IsType judges one error.
+func (msg *Error) IsType(flags ErrorType) bool {
+	return (msg.Type & flags) > 0
+}
+
+// Unwrap returns the wrapped error, to allow interoperability with errors.Is(), errors.As() and errors.Unwrap()
+func (msg *Error) Unwrap() error {
+	return msg.Err
+}
+
+// ByType returns a readonly copy filtered the byte.
+// ie ByType(jin.ErrorTypePublic) returns a slice of errors with type=ErrorTypePublic.
+func (a errorMsgs) ByType(typ ErrorType) errorMsgs {
+	if len(a) == 0 {
+		return nil
+	}
+	if typ == ErrorTypeAny {
+		return a
+	}
+	var result errorMsgs
+	for _, msg := range a {
+		if msg.IsType(typ) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Last returns the last error in the slice. It returns nil if the array is empty.
+// Shortcut for errors[len(errors)-1].
+func (a errorMsgs) Last() *Error {
+	if length := len(a); length > 0 {
+		return a[length-1]
+	}
+	return nil
+}
+
+// Errors returns an array with all the error messages.
+// Example:
+//
+//	c.Error(errors.New("first"))
+//	c.Error(errors.New("second"))
+//	c.Error(errors.New("third"))
+//	c.Errors.Errors() // == []string{"first", "second", "third"}
+func (a errorMsgs) Errors() []string {
+	if len(a) == 0 {
+		return nil
+	}
+	errorStrings := make([]string, len(a))
+	for i, err := range a {
+		errorStrings[i] = err.Error()
+	}
+	return errorStrings
+}
+
+// JSON returns the JSON representation of the errors: nil when empty, the single
+// error when there is one and an array otherwise.
+func (a errorMsgs) JSON() any {
+	switch length := len(a); length {
+	case 0:
+		return nil
+	case 1:
+		return a.Last().JSON()
+	default:
+		jsonData := make([]any, length)
+		for i, err := range a {
+			jsonData[i] = err.JSON()
+		}
+		return jsonData
+	}
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (a errorMsgs) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.JSON())
+}
+
+func (a errorMsgs) String() string {
+	if len(a) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	for i, msg := range a {
+		fmt.Fprintf(&buffer, "Error #%02d: %s\n", i+1, msg.Err)
+		if msg.Meta != nil {
+			fmt.Fprintf(&buffer, "     Meta: %v\n", msg.Meta)
+		}
+	}
+	return buffer.String()
+}
+
+// ErrorHandlerFunc renders the response for the errors recorded in Context.Errors,
+// err being the last of them.
+type ErrorHandlerFunc func(c *Context, err error)
+
+// DefaultErrorHandler is the ErrorHandlerFunc used when Engine.ErrorHandler is nil.
+// The error is first mapped with the error registries, see RouterGroup.Errors.
+// Otherwise binding errors abort with http.StatusBadRequest, public errors are
+// rendered as JSON with http.StatusInternalServerError, and anything else aborts
+// with http.StatusInternalServerError and an empty body, so no internal detail
+// leaks to the client.
+func DefaultErrorHandler(c *Context, err error) {
+	if code, body, ok := c.lookupError(err); ok {
+		if body == nil {
+			c.AbortWithStatus(code)
+			return
+		}
+		c.AbortWithStatusJSON(code, body)
+		return
+	}
+	if public := c.Errors.ByType(ErrorTypePublic); len(public) > 0 {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, public.JSON())
+		return
+	}
+	if len(c.Errors.ByType(ErrorTypeBind)) > 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	c.AbortWithStatus(http.StatusInternalServerError)
+}
+
+// ErrorRegistry maps errors to the status code and body of the response rendered
+// by DefaultErrorHandler. Mappings are tried in registration order.
+type ErrorRegistry struct {
+	mappings []errorMapping
+}
+
+type errorMapping struct {
+	code  int
+	match func(err error) (body any, ok bool)
+}
+
+// Register maps the errors matching target with errors.Is to the status code.
+// The response body is rendered as JSON, only the status code is written if body is nil.
+func (r *ErrorRegistry) Register(target error, code int, body any) *ErrorRegistry {
+	r.mappings = append(r.mappings, errorMapping{
+		code: code,
+		match: func(err error) (any, bool) {
+			return body, errors.Is(err, target)
+		},
+	})
+	return r
+}
+
+// RegisterError maps the errors matching E with errors.As to the status code.
+// The response body is built by body and rendered as JSON, only the status code
+// is written if body is nil.
+//
+//	jin.RegisterError(engine.Errors(), http.StatusNotFound, func(err *NotFoundError) any {
+//	    return jin.H{"error": err.Error(), "resource": err.Resource}
+//	})
+func RegisterError[E error](r *ErrorRegistry, code int, body func(err E) any) *ErrorRegistry {
+	r.mappings = append(r.mappings, errorMapping{
+		code: code,
+		match: func(err error) (any, bool) {
+			var target E
+			if !errors.As(err, &target) {
+				return nil, false
+			}
+			if body == nil {
+				return nil, true
+			}
+			return body(target), true
+		},
+	})
+	return r
+}
+
+// Lookup returns the status code and body err is mapped to, and whether a mapping was found.
+func (r *ErrorRegistry) Lookup(err error) (code int, body any, ok bool) {
+	for _, mapping := range r.mappings {
+		if body, ok = mapping.match(err); ok {
+			return mapping.code, body, true
+		}
+	}
+	return 0, nil, false
+}
@@ -0,0 +1,256 @@
+package jin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	baseError := errors.New("test error")
+	err := &Error{
+		Err:  baseError,
+		Type: ErrorTypePrivate,
+	}
+	assert.Equal(t, err.Error(), baseError.Error())
+	assert.Equal(t, H{"error": baseError.Error()}, err.JSON())
+
+	assert.Equal(t, err.SetType(ErrorTypePublic), err)
+	assert.Equal(t, ErrorTypePublic, err.Type)
+	assert.True(t, err.IsType(ErrorTypePublic))
+	assert.False(t, err.IsType(ErrorTypePrivate))
+
+	assert.Equal(t, err.SetMeta("some data"), err)
+	assert.Equal(t, "some data", err.Meta)
+	assert.Equal(t, H{
+		"error": baseError.Error(),
+		"meta":  "some data",
+	}, err.JSON())
+
+	jsonBytes, _ := json.Marshal(err)
+	assert.Equal(t, "{\"error\":\"test error\",\"meta\":\"some data\"}", string(jsonBytes))
+
+	err.SetMeta(H{
+		"status": "200",
+		"data":   "some data",
+	})
+	assert.Equal(t, H{
+		"error":  baseError.Error(),
+		"status": "200",
+		"data":   "some data",
+	}, err.JSON())
+
+	err.SetMeta(H{
+		"error":  "custom error",
+		"status": "200",
+		"data":   "some data",
+	})
+	assert.Equal(t, H{
+		"error":  "custom error",
+		"status": "200",
+		"data":   "some data",
+	}, err.JSON())
+
+	type customError struct {
+		status string
+		data   string
+	}
+	err.SetMeta(customError{status: "200", data: "other data"})
+	assert.Equal(t, customError{status: "200", data: "other data"}, err.JSON())
+}
+
+func TestErrorSlice(t *testing.T) {
+	errs := errorMsgs{
+		{Err: errors.New("first"), Type: ErrorTypePrivate},
+		{Err: errors.New("second"), Type: ErrorTypePrivate, Meta: "some data"},
+		{Err: errors.New("third"), Type: ErrorTypePublic, Meta: H{"status": "400"}},
+	}
+
+	assert.Equal(t, errs, errs.ByType(ErrorTypeAny))
+	assert.Equal(t, "third", errs.Last().Error())
+	assert.Equal(t, []string{"first", "second", "third"}, errs.Errors())
+	assert.Equal(t, []string{"third"}, errs.ByType(ErrorTypePublic).Errors())
+	assert.Equal(t, []string{"first", "second"}, errs.ByType(ErrorTypePrivate).Errors())
+	assert.Equal(t, []string{"first", "second", "third"}, errs.ByType(ErrorTypePublic|ErrorTypePrivate).Errors())
+	assert.Empty(t, errs.ByType(ErrorTypeBind))
+	assert.Empty(t, errs.ByType(ErrorTypeBind).String())
+
+	assert.Equal(t, `Error #01: first
+Error #02: second
+     Meta: some data
+Error #03: third
+     Meta: map[status:400]
+`, errs.String())
+	assert.Equal(t, []any{
+		H{"error": "first"},
+		H{"error": "second", "meta": "some data"},
+		H{"error": "third", "status": "400"},
+	}, errs.JSON())
+	jsonBytes, _ := json.Marshal(errs)
+	assert.Equal(t, "[{\"error\":\"first\"},{\"error\":\"second\",\"meta\":\"some data\"},{\"error\":\"third\",\"status\":\"400\"}]", string(jsonBytes))
+
+	errs = errorMsgs{
+		{Err: errors.New("first"), Type: ErrorTypePrivate},
+	}
+	assert.Equal(t, H{"error": "first"}, errs.JSON())
+	jsonBytes, _ = json.Marshal(errs)
+	assert.Equal(t, "{\"error\":\"first\"}", string(jsonBytes))
+
+	errs = errorMsgs{}
+	assert.Nil(t, errs.Last())
+	assert.Nil(t, errs.JSON())
+	assert.Empty(t, errs.String())
+	assert.Nil(t, errs.Errors())
+}
+
+type testErr struct {
+	str string
+}
+
+func (e *testErr) Error() string {
+	return e.str
+}
+
+func TestErrorUnwrap(t *testing.T) {
+	innerErr := &testErr{"some error"}
+
+	// 2 layers of wrapping : use 'fmt.Errorf("%w")' to wrap a jin.Error{}, which itself wraps innerErr
+	err := fmt.Errorf("wrapped: %w", &Error{
+		Err:  innerErr,
+		Type: ErrorTypeAny,
+	})
+
+	// check that 'errors.Is()' and 'errors.As()' behave as expected :
+	assert.True(t, errors.Is(err, innerErr))
+	var testErr *testErr
+	assert.True(t, errors.As(err, &testErr))
+}
+
+var errNotFound = errors.New("not found")
+
+type validationError struct {
+	Field string
+}
+
+func (e *validationError) Error() string {
+	return "invalid " + e.Field
+}
+
+func TestErrorRegistryLookup(t *testing.T) {
+	r := &ErrorRegistry{}
+	_, _, ok := r.Lookup(errNotFound)
+	assert.False(t, ok)
+
+	r.Register(errNotFound, http.StatusNotFound, nil)
+	RegisterError(r, http.StatusUnprocessableEntity, func(err *validationError) any {
+		return H{"field": err.Field}
+	})
+	RegisterError[*testErr](r, http.StatusConflict, nil)
+
+	code, body, ok := r.Lookup(fmt.Errorf("user: %w", errNotFound))
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Nil(t, body)
+
+	code, body, ok = r.Lookup(&Error{Err: &validationError{Field: "name"}})
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.Equal(t, H{"field": "name"}, body)
+
+	code, _, ok = r.Lookup(&testErr{"conflict"})
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusConflict, code)
+
+	_, _, ok = r.Lookup(errors.New("other"))
+	assert.False(t, ok)
+}
+
+func TestDefaultErrorHandler(t *testing.T) {
+	engine := New()
+	engine.Errors().Register(errNotFound, http.StatusNotFound, H{"error": "not found"})
+	RegisterError(engine.Errors(), http.StatusBadRequest, func(err *validationError) any {
+		return H{"error": err.Error()}
+	})
+
+	admin := engine.Group("/admin")
+	// the group overrides the engine wide mapping, the others are inherited
+	admin.Errors().Register(errNotFound, http.StatusForbidden, nil)
+	nested := admin.Group("/nested")
+
+	failWith := func(err error) HandlerFunc {
+		return func() error { return err }
+	}
+	engine.GET("/missing", failWith(errNotFound))
+	engine.GET("/invalid", failWith(&validationError{Field: "age"}))
+	engine.GET("/private", failWith(errors.New("database is down")))
+	engine.GET("/public", func(c *Context) {
+		c.Error(errors.New("try again later")).SetType(ErrorTypePublic)
+	})
+	engine.GET("/bind", func(c *Context) {
+		c.Error(errors.New("bad json")).SetType(ErrorTypeBind)
+	})
+	engine.GET("/written", func(c *Context) {
+		c.String(http.StatusOK, "fine")
+		c.Error(errNotFound)
+	})
+	admin.GET("/missing", failWith(errNotFound))
+	nested.GET("/missing", failWith(errNotFound))
+	nested.GET("/invalid", failWith(&validationError{Field: "role"}))
+	engine.NoRoute(func(c *Context) {
+		c.Error(errNotFound)
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/missing", http.StatusNotFound, `{"error":"not found"}`},
+		{"/invalid", http.StatusBadRequest, `{"error":"invalid age"}`},
+		{"/private", http.StatusInternalServerError, ""},
+		{"/public", http.StatusInternalServerError, `{"error":"try again later"}`},
+		{"/bind", http.StatusBadRequest, ""},
+		{"/written", http.StatusOK, "fine"},
+		{"/admin/missing", http.StatusForbidden, ""},
+		{"/admin/nested/missing", http.StatusForbidden, ""},
+		{"/admin/nested/invalid", http.StatusBadRequest, `{"error":"invalid role"}`},
+		{"/no/such/route", http.StatusNotFound, `{"error":"not found"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
+
+func TestErrorHandlerRunsOnce(t *testing.T) {
+	engine := New()
+	calls := 0
+	engine.ErrorHandler = func(c *Context, err error) {
+		calls++
+		assert.Equal(t, "second", err.Error())
+		c.JSON(http.StatusTeapot, c.Errors.Errors())
+	}
+	engine.GET("/", func(c *Context) {
+		c.Error(errors.New("first"))
+		c.Next()
+	}, func() error {
+		return errors.New("second")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, `["first","second"]`, w.Body.String())
+}
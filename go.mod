@@ -1,6 +1,6 @@
 module github.com/juanjiTech/jin
 
-go 1.20
+go 1.24.0
 
 require (
 	github.com/juanjiTech/inject/v2 v2.0.1
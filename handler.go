@@ -2,9 +2,12 @@ package jin
 
 import (
 	"fmt"
-	"github.com/juanjiTech/inject/v2"
 	"net/http"
 	"reflect"
+	"sync"
+
+	"github.com/juanjiTech/inject/v2"
+	"github.com/juanjiTech/jin/render"
 )
 
 // HandlerFunc defines the handler used by Jin middleware as return value.
@@ -40,7 +43,51 @@ func (invoke httpHandlerFuncInvoker) Invoke(args []interface{}) ([]reflect.Value
 	return nil, nil
 }
 
+// errorPassthrough is the HandlerFunc returned by PassErrors.
+type errorPassthrough struct {
+	handler HandlerFunc
+}
+
+// PassErrors wraps a handler so that a non-nil error it returns is only mapped into
+// the injector, like any other return value, instead of being pushed to Context.Errors
+// and aborting the chain. The next handlers can ask for the error as a parameter.
+//
+//	router.GET("/", jin.PassErrors(func() (string, error) {
+//	    return load()
+//	}), func(c *jin.Context, s string, err error) {
+//	    ...
+//	})
+func PassErrors(handler HandlerFunc) HandlerFunc {
+	if handler == nil {
+		panic("handler can not be nil")
+	}
+	return errorPassthrough{handler: handler}
+}
+
+// unwrapHandler returns the handler wrapped by PassErrors and whether it was wrapped.
+func unwrapHandler(h HandlerFunc) (HandlerFunc, bool) {
+	if p, ok := h.(errorPassthrough); ok {
+		return p.handler, true
+	}
+	return h, false
+}
+
+// resultError returns the error a handler returned as its last value, if any.
+func resultError(fnType reflect.Type, values []reflect.Value) error {
+	n := len(values)
+	if n == 0 || fnType.Out(n-1) != errorType {
+		return nil
+	}
+	err, _ := values[n-1].Interface().(error)
+	return err
+}
+
 func fastInvokeWarpHandler(h HandlerFunc) HandlerFunc {
+	if p, ok := h.(errorPassthrough); ok {
+		p.handler = fastInvokeWarpHandler(p.handler)
+		return p
+	}
+
 	if reflect.TypeOf(h).Kind() != reflect.Func {
 		panic(fmt.Sprintf("handler must be a callable function, but got %T", h))
 	}
@@ -57,9 +104,50 @@ func fastInvokeWarpHandler(h HandlerFunc) HandlerFunc {
 	case http.HandlerFunc:
 		return httpHandlerFuncInvoker(v)
 	}
+
+	invokersMu.RLock()
+	wrap := invokers[reflect.TypeOf(h)]
+	invokersMu.RUnlock()
+	if wrap != nil {
+		return wrap(h)
+	}
 	return h
 }
 
+var (
+	invokersMu sync.RWMutex
+	invokers   = make(map[reflect.Type]func(HandlerFunc) HandlerFunc)
+)
+
+// RegisterInvoker registers an inject.FastInvoker adapter for the handlers of type F,
+// which must be a func type. The handlers of this exact type are then invoked through
+// the adapter instead of reflect.Call. It is meant to be called from the init
+// functions written by cmd/jin-invokergen:
+//
+//	type jinInvoker0 func(*jin.Context, *DB) string
+//
+//	func (invoke jinInvoker0) Invoke(args []any) ([]reflect.Value, error) {
+//	    r0 := invoke(args[0].(*jin.Context), args[1].(*DB))
+//	    return []reflect.Value{reflect.ValueOf(&r0).Elem()}, nil
+//	}
+//
+//	func init() {
+//	    jin.RegisterInvoker(func(h func(*jin.Context, *DB) string) inject.FastInvoker {
+//	        return jinInvoker0(h)
+//	    })
+//	}
+func RegisterInvoker[F any](wrap func(F) inject.FastInvoker) {
+	t := reflect.TypeOf((*F)(nil)).Elem()
+	if t.Kind() != reflect.Func {
+		panic(fmt.Sprintf("invoker must be registered for a func type, but got %v", t))
+	}
+	invokersMu.Lock()
+	invokers[t] = func(h HandlerFunc) HandlerFunc {
+		return wrap(h.(F))
+	}
+	invokersMu.Unlock()
+}
+
 func fastInvokeWarpHandlerChain(hc HandlersChain) {
 	for i, handlerFunc := range hc {
 		if handlerFunc == nil {
@@ -68,3 +156,106 @@ func fastInvokeWarpHandlerChain(hc HandlersChain) {
 		hc[i] = fastInvokeWarpHandler(handlerFunc)
 	}
 }
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+	// defaultProduces is offered to the client when the route doesn't declare what it produces.
+	defaultProduces = []string{MIMEJSON, MIMEYAML}
+	// defaultStringProduces is offered instead of defaultProduces when the result is a string.
+	defaultStringProduces = []string{MIMEPlain, MIMEJSON, MIMEYAML}
+)
+
+// Produces returns a middleware which declares the content types the route produces,
+// in order of preference. They are negotiated against the Accept header when the
+// final handler returns a value instead of writing the response itself.
+//
+//	router.GET("/user/:id", jin.Produces(jin.MIMEYAML, jin.MIMEJSON), func(c *jin.Context) *User {
+//	    return findUser(c.Param("id"))
+//	})
+func Produces(contentTypes ...string) HandlerFunc {
+	if len(contentTypes) == 0 {
+		panic("there must be at least one content type")
+	}
+	return func(c *Context) {
+		c.produces = contentTypes
+	}
+}
+
+// renderResult writes the return values of the final handler to the response.
+// Supported shapes are a render.Render, a plain value, (int, T) where the int is
+// the status code, and any of them followed by an error. Nothing is rendered if
+// the handler already wrote the response or returned a non-nil error, which is
+// up to the Engine.ErrorHandler.
+func (c *Context) renderResult(fnType reflect.Type, values []reflect.Value) {
+	if c.Writer.Written() {
+		return
+	}
+
+	n := len(values)
+	if fnType.Out(n-1) == errorType {
+		if resultError(fnType, values) != nil {
+			return
+		}
+		n--
+	}
+
+	code := c.Writer.Status()
+	switch {
+	case n == 1:
+	case n == 2 && fnType.Out(0).Kind() == reflect.Int:
+		code = int(values[0].Int())
+		values = values[1:]
+	default:
+		return
+	}
+
+	result := values[0]
+	switch result.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+		if result.IsNil() {
+			c.Status(code)
+			return
+		}
+	}
+	c.Render(code, c.resultRender(result.Interface()))
+}
+
+// resultRender picks the render.Render used for a handler result. Results that are
+// already a render.Render are used as is, raw bytes are written with the first
+// produced content type, anything else is rendered in the format negotiated between
+// the produced content types and the Accept header.
+func (c *Context) resultRender(obj any) render.Render {
+	if r, ok := obj.(render.Render); ok {
+		return r
+	}
+
+	offered := c.produces
+	if len(offered) == 0 {
+		offered = defaultProduces
+		if _, ok := obj.(string); ok {
+			offered = defaultStringProduces
+		}
+	}
+
+	if data, ok := obj.([]byte); ok {
+		contentType := "application/octet-stream"
+		if len(c.produces) > 0 {
+			contentType = c.produces[0]
+		}
+		return render.Data{ContentType: contentType, Data: data}
+	}
+
+	format := c.NegotiateFormat(offered...)
+	if format == "" {
+		format = offered[0]
+	}
+	switch format {
+	case MIMEYAML, MIMEYAML2:
+		return render.YAML{Data: obj}
+	case MIMEPlain:
+		return render.String{Format: "%v", Data: []any{obj}}
+	default:
+		return render.JSON{Data: obj}
+	}
+}
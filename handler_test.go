@@ -1,11 +1,14 @@
 package jin
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
 
+	"github.com/juanjiTech/inject/v2"
+	"github.com/juanjiTech/jin/render"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -64,3 +67,149 @@ func TestFastInvokeWarpHandlerChain(t *testing.T) {
 	_, ok2 := chain[2].(httpHandlerFuncInvoker)
 	assert.True(t, ok2)
 }
+
+type testInvoker func(*Context, error) (string, error)
+
+func (invoke testInvoker) Invoke(args []any) ([]reflect.Value, error) {
+	a1, _ := args[1].(error)
+	r0, r1 := invoke(args[0].(*Context), a1)
+	return []reflect.Value{reflect.ValueOf(&r0).Elem(), reflect.ValueOf(&r1).Elem()}, nil
+}
+
+func TestRegisterInvoker(t *testing.T) {
+	RegisterInvoker(func(h func(*Context, error) (string, error)) inject.FastInvoker {
+		return testInvoker(h)
+	})
+	defer func() {
+		invokersMu.Lock()
+		delete(invokers, reflect.TypeOf(func(*Context, error) (string, error) { return "", nil }))
+		invokersMu.Unlock()
+	}()
+
+	assert.Panics(t, func() {
+		RegisterInvoker(func(h string) inject.FastInvoker { return nil })
+	})
+
+	router := New()
+	router.GET("/", PassErrors(func() error { return nil }), func(c *Context, err error) (string, error) {
+		return c.FullPath(), err
+	})
+	_, ok := router.table().trees.get(http.MethodGet).handlers[1].(testInvoker)
+	assert.True(t, ok)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/", w.Body.String())
+}
+
+type resultUser struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+func TestHandlerResultRender(t *testing.T) {
+	engine := New()
+	engine.GET("/render", func() render.Render {
+		return render.String{Format: "rendered"}
+	})
+	engine.GET("/value", func() resultUser {
+		return resultUser{Name: "jin"}
+	})
+	engine.GET("/pointer", func() *resultUser {
+		return &resultUser{Name: "jin"}
+	})
+	engine.GET("/nil", func() *resultUser {
+		return nil
+	})
+	engine.GET("/string", func() string {
+		return "hello"
+	})
+	engine.GET("/bytes", func() []byte {
+		return []byte("raw")
+	})
+	engine.GET("/status", func() (int, resultUser) {
+		return http.StatusCreated, resultUser{Name: "created"}
+	})
+	engine.GET("/ok", func() (resultUser, error) {
+		return resultUser{Name: "ok"}, nil
+	})
+	engine.GET("/error", func() (resultUser, error) {
+		return resultUser{}, errors.New("boom")
+	})
+	engine.GET("/produces", Produces(MIMEYAML), func() resultUser {
+		return resultUser{Name: "yaml"}
+	})
+	engine.GET("/written", func(c *Context) string {
+		c.String(http.StatusAccepted, "by hand")
+		return "ignored"
+	})
+	engine.GET("/middleware", func() string {
+		return "not the final handler"
+	}, func(c *Context, s string) {
+		c.String(http.StatusOK, "got "+s)
+	})
+
+	tests := []struct {
+		path        string
+		accept      string
+		code        int
+		body        string
+		contentType string
+	}{
+		{"/render", "", http.StatusOK, "rendered", "text/plain; charset=utf-8"},
+		{"/value", "", http.StatusOK, `{"name":"jin"}`, "application/json; charset=utf-8"},
+		{"/value", "application/yaml", http.StatusOK, "name: jin\n", "application/yaml; charset=utf-8"},
+		{"/value", "text/html, application/*;q=0.9", http.StatusOK, `{"name":"jin"}`, "application/json; charset=utf-8"},
+		{"/pointer", "", http.StatusOK, `{"name":"jin"}`, "application/json; charset=utf-8"},
+		{"/nil", "", http.StatusOK, "", ""},
+		{"/string", "", http.StatusOK, "hello", "text/plain; charset=utf-8"},
+		{"/string", "application/json", http.StatusOK, `"hello"`, "application/json; charset=utf-8"},
+		{"/bytes", "", http.StatusOK, "raw", "application/octet-stream"},
+		{"/status", "", http.StatusCreated, `{"name":"created"}`, "application/json; charset=utf-8"},
+		{"/ok", "", http.StatusOK, `{"name":"ok"}`, "application/json; charset=utf-8"},
+		{"/error", "", http.StatusInternalServerError, "", ""},
+		{"/produces", "", http.StatusOK, "name: yaml\n", "application/yaml; charset=utf-8"},
+		{"/produces", "application/json", http.StatusOK, "name: yaml\n", "application/yaml; charset=utf-8"},
+		{"/written", "", http.StatusAccepted, "by hand", "text/plain; charset=utf-8"},
+		{"/middleware", "", http.StatusOK, "got not the final handler", "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.accept, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestProducesPanicsWithoutContentType(t *testing.T) {
+	assert.Panics(t, func() { Produces() })
+}
+
+func TestPassErrors(t *testing.T) {
+	assert.Panics(t, func() { PassErrors(nil) })
+
+	handler := func(c *Context) error { return nil }
+	wrapped := PassErrors(handler)
+	unwrapped, ok := unwrapHandler(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, reflect.ValueOf(handler).Pointer(), reflect.ValueOf(unwrapped).Pointer())
+	assert.Equal(t, nameOfFunction(handler), nameOfFunction(wrapped))
+
+	_, ok = unwrapHandler(handler)
+	assert.False(t, ok)
+
+	// the wrapped handler still gets the fast invoker treatment
+	wrapped = fastInvokeWarpHandler(PassErrors(func(c *Context) {}))
+	unwrapped, ok = unwrapHandler(wrapped)
+	assert.True(t, ok)
+	_, ok = unwrapped.(ContextInvoker)
+	assert.True(t, ok)
+}
@@ -0,0 +1,111 @@
+package jin
+
+import (
+	"net"
+	"strings"
+)
+
+// hostPattern is a host pattern of Engine.Host.
+type hostPattern struct {
+	pattern string
+	// labels are the dot separated labels of the pattern, the wildcard ones with
+	// their braces.
+	labels []string
+	// params is the number of wildcard labels.
+	params uint16
+}
+
+// hostTree holds the routes registered for a host pattern.
+type hostTree struct {
+	*hostPattern
+	trees methodTrees
+}
+
+// Host returns a router group whose routes are only matched for the requests to a
+// host matching pattern, like "api.example.com" or "{tenant}.example.com". A label
+// between braces matches any label, which is available as a path parameter under
+// the name between the braces. The port of the request, if any, is ignored and the
+// labels are compared case-insensitively.
+//
+//	tenants := router.Host("{tenant}.example.com")
+//	tenants.GET("/users/:id", func(c *jin.Context) {
+//	    tenant := c.Param("tenant")
+//	    ...
+//	})
+//
+// Each host pattern has its own routes, the patterns without wildcards being tried
+// first. The requests to a host matching none of them are routed with the routes
+// registered outside of a Host group.
+func (engine *Engine) Host(pattern string, handlers ...HandlerFunc) *RouterGroup {
+	return &RouterGroup{
+		Handlers: engine.combineHandlers(handlers),
+		basePath: "/",
+		engine:   engine,
+		parent:   &engine.RouterGroup,
+		host:     parseHostPattern(pattern),
+	}
+}
+
+func parseHostPattern(pattern string) *hostPattern {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	host := &hostPattern{pattern: pattern, labels: strings.Split(pattern, ".")}
+	for _, label := range host.labels {
+		switch {
+		case label == "" || label == "{}":
+			panic("host pattern '" + pattern + "' has an empty label")
+		case strings.ContainsAny(label, ":/"):
+			panic("host pattern '" + pattern + "' must only have a host name")
+		case label[0] == '{' && label[len(label)-1] == '}':
+			host.params++
+		case strings.ContainsAny(label, "{}"):
+			panic("wildcards must span a whole label in host pattern '" + pattern + "'")
+		}
+	}
+	return host
+}
+
+// matchHost returns the route trees of the host of the request, appending the
+// values of its wildcard labels to params.
+func (table *routeTable) matchHost(requestHost string, params *Params) methodTrees {
+	if h, _, err := net.SplitHostPort(requestHost); err == nil {
+		requestHost = h
+	}
+	requestHost = strings.TrimSuffix(requestHost, ".")
+	for _, host := range table.hosts {
+		if host.match(requestHost) {
+			host.appendParams(requestHost, params)
+			return host.trees
+		}
+	}
+	return table.trees
+}
+
+func (host *hostPattern) match(requestHost string) bool {
+	rest := requestHost
+	for i, label := range host.labels {
+		value, tail, found := strings.Cut(rest, ".")
+		if found == (i == len(host.labels)-1) || value == "" {
+			// fewer or more labels
+			return false
+		}
+		if label[0] != '{' && !strings.EqualFold(label, value) {
+			return false
+		}
+		rest = tail
+	}
+	return true
+}
+
+func (host *hostPattern) appendParams(requestHost string, params *Params) {
+	if host.params == 0 {
+		return
+	}
+	rest := requestHost
+	for _, label := range host.labels {
+		var value string
+		value, rest, _ = strings.Cut(rest, ".")
+		if label[0] == '{' {
+			*params = append(*params, Param{Key: label[1 : len(label)-1], Value: value})
+		}
+	}
+}
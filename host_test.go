@@ -0,0 +1,145 @@
+package jin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineHost(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "default %s", c.Param("id"))
+	})
+	api := router.Host("api.example.com")
+	api.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "api %s", c.Param("id"))
+	})
+	tenants := router.Host("{tenant}.example.com").Group("/v1")
+	tenants.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "tenant %s %s %v", c.Param("tenant"), c.Param("id"), c.Params)
+	})
+	router.Host("{a}.{b}.example.com").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.Param("a"), c.Param("b"))
+	})
+	assert.Equal(t, api.host, router.Host("API.example.com.").host)
+
+	tests := []struct {
+		host, path string
+		code       int
+		body       string
+	}{
+		{"api.example.com", "/users/1", http.StatusOK, "api 1"},
+		{"API.Example.com:8080", "/users/1", http.StatusOK, "api 1"},
+		{"acme.example.com", "/v1/users/2", http.StatusOK, "tenant acme 2 [{tenant acme} {id 2}]"},
+		{"acme.example.com.", "/v1/users/2", http.StatusOK, "tenant acme 2 [{tenant acme} {id 2}]"},
+		{"acme.example.com", "/users/2", http.StatusNotFound, "404 page not found"},
+		{"x.y.example.com", "/", http.StatusOK, "x y"},
+		{"example.com", "/users/3", http.StatusOK, "default 3"},
+		{"a.b.c.example.com", "/users/3", http.StatusOK, "default 3"},
+		{"localhost:8080", "/users/3", http.StatusOK, "default 3"},
+		{"[::1]:8080", "/users/3", http.StatusOK, "default 3"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.code, w.Code, tt.host+tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.host+tt.path)
+	}
+
+	routes := router.Routes()
+	assert.Len(t, routes, 4)
+	hosts := map[string]string{}
+	for _, route := range routes {
+		hosts[route.Host] = route.Path
+	}
+	assert.Equal(t, map[string]string{
+		"":                     "/users/:id",
+		"api.example.com":      "/users/:id",
+		"{tenant}.example.com": "/v1/users/:id",
+		"{a}.{b}.example.com":  "/",
+	}, hosts)
+}
+
+func TestEngineHostMethodNotAllowed(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.POST("/items", handlerTest1)
+	router.Host("api.example.com").GET("/items", handlerTest1)
+
+	req := httptest.NewRequest(http.MethodPut, "/items", nil)
+	req.Host = "api.example.com"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	req = httptest.NewRequest(http.MethodPut, "/other", nil)
+	req.Host = "api.example.com"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestEngineHostPrecedence(t *testing.T) {
+	router := New()
+	router.Host("{sub}.example.com").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "wildcard")
+	})
+	router.Host("www.example.com").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "www")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "www.example.com"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "www", w.Body.String())
+}
+
+func TestEngineHostInvalidPattern(t *testing.T) {
+	router := New()
+	for _, pattern := range []string{"", "api..example.com", "{}.example.com", "api.example.com:8080", "x{tenant}.example.com"} {
+		assert.Panics(t, func() { router.Host(pattern) }, pattern)
+	}
+}
+
+func TestEngineHostMatchAndRemoveRoute(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", handlerTest1)
+	api := router.Host("api.example.com")
+	api.GET("/users/:id", handlerTest1)
+	tenants := router.Host("{tenant}.example.com").Group("/v1")
+	tenants.GET("/users/:id", handlerTest1)
+
+	route, params, ok := router.MatchHostRoute("acme.example.com:8080", http.MethodGet, "/v1/users/2")
+	assert.True(t, ok)
+	assert.Equal(t, "{tenant}.example.com", route.Host)
+	assert.Equal(t, Params{{"tenant", "acme"}, {"id", "2"}}, params)
+	route, _, ok = router.MatchHostRoute("api.example.com", http.MethodGet, "/users/1")
+	assert.True(t, ok)
+	assert.Equal(t, "api.example.com", route.Host)
+	route, _, ok = router.MatchHostRoute("localhost", http.MethodGet, "/users/1")
+	assert.True(t, ok)
+	assert.Equal(t, "", route.Host)
+	_, _, ok = router.MatchHostRoute("acme.example.com", http.MethodGet, "/users/1")
+	assert.False(t, ok)
+
+	// the routes are removed from the trees of their host only
+	assert.ErrorIs(t, router.Host("www.example.com").RemoveRoute(http.MethodGet, "/users/:id"), ErrRouteNotFound)
+	assert.ErrorIs(t, tenants.RemoveRoute(http.MethodGet, "/users/:id/posts"), ErrRouteNotFound)
+	assert.NoError(t, tenants.RemoveRoute(http.MethodGet, "/users/:id"))
+	assert.NoError(t, router.Host("API.example.com").RemoveRoute(http.MethodGet, "/users/:id"))
+	assert.ErrorIs(t, api.RemoveRoute(http.MethodGet, "/users/:id"), ErrRouteNotFound)
+	assert.Empty(t, router.table().hosts)
+	route, _, ok = router.MatchHostRoute("api.example.com", http.MethodGet, "/users/1")
+	assert.True(t, ok)
+	assert.Equal(t, "", route.Host)
+
+	_, _, ok = router.MatchRoute(http.MethodGet, "/users/1")
+	assert.True(t, ok)
+	assert.Len(t, router.Routes(), 1)
+}
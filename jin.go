@@ -1,10 +1,16 @@
 package jin
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"path"
+	"reflect"
 	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/juanjiTech/inject/v2"
 	"github.com/juanjiTech/jin/internal/bytesconv"
@@ -12,14 +18,50 @@ import (
 	"golang.org/x/net/http2/h2c"
 )
 
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
 var (
 	default404Body = []byte("404 page not found")
 	default405Body = []byte("405 method not allowed")
 )
 
+// ErrRouteNotFound is returned by Engine.URL when no route has the given name, and
+// by RouterGroup.RemoveRoute when no route is registered for the method and path.
+var ErrRouteNotFound = errors.New("route not found")
+
 var regSafePrefix = regexp.MustCompile("[^a-zA-Z0-9/-]+")
 var regRemoveRepeatedChar = regexp.MustCompile("/{2,}")
 
+// RouteInfo represents a request route's specification which contains method and path and its handler.
+type RouteInfo struct {
+	// Host is the host pattern of the route, see Engine.Host, empty for the routes
+	// of any host.
+	Host string
+	// Version is the API version served by the route, see RouterGroup.Version,
+	// empty for the routes outside of a versioned group.
+	Version     string
+	Method      string
+	Path        string
+	Handler     string
+	HandlerFunc HandlerFunc
+	// Handlers is the number of handlers of the route, middleware included.
+	Handlers int
+	// Middlewares are the names of the handlers running before the Handler.
+	Middlewares []string
+	// HandlersChain holds the handlers of the route, middleware included.
+	// It must not be modified.
+	HandlersChain HandlersChain
+	// Name is the name of the route, see Route.Name.
+	Name string
+	// Meta and Tags are attached to the route with Route.Meta and Route.Tags.
+	// They are shared by all the requests and must not be modified.
+	Meta map[string]any
+	Tags []string
+}
+
+// RoutesInfo defines a RouteInfo slice.
+type RoutesInfo []RouteInfo
+
 type Engine struct {
 	inject.Injector
 	RouterGroup
@@ -65,14 +107,40 @@ type Engine struct {
 	// UseH2C enable h2c support.
 	UseH2C bool
 
-	allNoRoute  HandlersChain
-	allNoMethod HandlersChain
-	noRoute     HandlersChain
-	noMethod    HandlersChain
-	trees       methodTrees
-	maxParams   uint16
-	maxSections uint16
-	ctxPool     sync.Pool
+	// PanicOnReleasedContext makes the context.Context methods of a Context panic when it is
+	// used after ServeHTTP returned it to the pool, instead of behaving like a canceled context.
+	// It helps finding the Contexts retained by mistake, which have to be copied with Context.Copy.
+	PanicOnReleasedContext bool
+
+	// ErrorHandler renders the response once the handlers chain is done, if errors were recorded
+	// in Context.Errors and nothing has been written yet. It is called with the last error.
+	// A handler or middleware returning a non-nil error records it and aborts the chain.
+	// DefaultErrorHandler is used if it is nil.
+	ErrorHandler ErrorHandlerFunc
+
+	// DefaultVersion is the API version served by the versioned routes to the requests
+	// asking for no version, or for one below all the registered ones. See
+	// RouterGroup.Version.
+	DefaultVersion string
+
+	// MaxMultipartMemory value of 'maxMemory' param that is given to http.Request's ParseMultipartForm
+	// method call.
+	MaxMultipartMemory int64
+
+	allNoRoute    HandlersChain
+	allNoMethod   HandlersChain
+	noRoute       HandlersChain
+	noMethod      HandlersChain
+	noRoutePlans  []handlerPlan
+	noMethodPlans []handlerPlan
+	providers     map[reflect.Type]*provider
+	routeNames    map[string]string
+	// routes is replaced on each registration, under routesMu which also guards
+	// routeNames and routeSeq.
+	routes   atomic.Pointer[routeTable]
+	routesMu sync.RWMutex
+	routeSeq uint64
+	ctxPool  sync.Pool
 }
 
 func New() *Engine {
@@ -84,18 +152,19 @@ func New() *Engine {
 			basePath: "/",
 			root:     true,
 		},
+		MaxMultipartMemory: defaultMultipartMemory,
 	}
 	engine.RouterGroup.engine = engine
 	engine.ctxPool.New = func() any {
-		return engine.allocateContext(engine.maxParams)
+		return engine.allocateContext(engine.table())
 	}
 	return engine
 }
 
-func (engine *Engine) allocateContext(maxParams uint16) *Context {
-	v := make(Params, 0, maxParams)
-	skippedNodes := make([]skippedNode, 0, engine.maxSections)
-	return &Context{params: &v, skippedNodes: &skippedNodes}
+func (engine *Engine) allocateContext(table *routeTable) *Context {
+	v := make(Params, 0, table.maxParams)
+	skippedNodes := make([]skippedNode, 0, table.maxSections)
+	return &Context{engine: engine, params: &v, skippedNodes: &skippedNodes}
 }
 
 // Default returns an Engine instance with the Logger and Recovery middleware already attached.
@@ -139,13 +208,17 @@ func (engine *Engine) Use(middleware ...HandlerFunc) IRoutes {
 
 func (engine *Engine) rebuild404Handlers() {
 	engine.allNoRoute = engine.combineHandlers(engine.noRoute)
+	fastInvokeWarpHandlerChain(engine.allNoRoute)
+	engine.noRoutePlans = newChainPlan(engine.allNoRoute)
 }
 
 func (engine *Engine) rebuild405Handlers() {
 	engine.allNoMethod = engine.combineHandlers(engine.noMethod)
+	fastInvokeWarpHandlerChain(engine.allNoMethod)
+	engine.noMethodPlans = newChainPlan(engine.allNoMethod)
 }
 
-func (engine *Engine) addRoute(method, path string, handlers HandlersChain) {
+func (engine *Engine) addRoute(method, path string, handlers HandlersChain, route *routeEntry) {
 	if path[0] != '/' {
 		panic("path must begin with '/'")
 	}
@@ -157,33 +230,189 @@ func (engine *Engine) addRoute(method, path string, handlers HandlersChain) {
 	}
 
 	fastInvokeWarpHandlerChain(handlers)
+	route.plans = newChainPlan(handlers)
+	route.info = newRouteInfo(method, path, handlers)
 
 	debugPrintRoute(method, path, handlers)
 
-	root := engine.trees.get(method)
-	if root == nil {
-		root = new(node)
-		root.fullPath = "/"
-		engine.trees = append(engine.trees, methodTree{method: method, root: root})
+	var hostParams uint16
+	if host := route.group.host; host != nil {
+		hostParams = host.params
+		route.info.Host = host.pattern
+	}
+	route.info.Version = route.group.version
+	engine.updateRoutes(func(table *routeTable) {
+		table.hostTrees(route.group.host).root(method).insertEntry(route)
+		engine.routeSeq++
+		route.seq = engine.routeSeq
+
+		// Update maxParams
+		if paramsCount := hostParams + countParams(path); paramsCount > table.maxParams {
+			table.maxParams = paramsCount
+		}
+
+		if sectionsCount := countSections(path); sectionsCount > table.maxSections {
+			table.maxSections = sectionsCount
+		}
+	})
+}
+
+// Routes returns a slice of registered routes, including some useful information, such as:
+// the http method, path and the handler name.
+func (engine *Engine) Routes() (routes RoutesInfo) {
+	engine.table().walk(func(route *routeEntry) {
+		routes = append(routes, route.info)
+	})
+	return routes
+}
+
+// MatchRoute reports the route a request with the given method and path would be handled
+// by, along with the path parameters, without handling it. The path is looked up as
+// is, except for the extra slashes removed if RemoveExtraSlash is set, so the
+// trailing slash and fixed path redirections are not taken into account. Only the
+// routes outside of the Host groups are looked up, see MatchHostRoute, and the first
+// version registered is reported for the versioned routes.
+func (engine *Engine) MatchRoute(method, path string) (route RouteInfo, params Params, ok bool) {
+	return engine.matchRoute(nil, method, path)
+}
+
+// MatchHostRoute is MatchRoute for a request to host, whose routes are looked up like
+// ServeHTTP does, the ones outside of the Host groups included. The values of the
+// wildcard labels of the host come first in params.
+func (engine *Engine) MatchHostRoute(host, method, path string) (route RouteInfo, params Params, ok bool) {
+	return engine.matchRoute(&host, method, path)
+}
+
+func (engine *Engine) matchRoute(host *string, method, path string) (route RouteInfo, params Params, ok bool) {
+	table := engine.table()
+	params = make(Params, 0, table.maxParams)
+	trees := table.trees
+	if host != nil {
+		trees = table.matchHost(*host, &params)
+	}
+	tree := trees[method]
+	if tree == nil {
+		return route, nil, false
+	}
+	if engine.RemoveExtraSlash {
+		path = cleanPath(path)
+	}
+
+	skippedNodes := make([]skippedNode, 0, table.maxSections)
+	value := tree.getValue(path, &params, &skippedNodes, false)
+	if value.handlers == nil {
+		return route, nil, false
+	}
+	if value.params != nil {
+		params = *value.params
+	}
+	return value.route.info, params, true
+}
+
+func newRouteInfo(method, path string, handlers HandlersChain) RouteInfo {
+	handlerFunc := handlers.Last()
+	route := RouteInfo{
+		Method:        method,
+		Path:          path,
+		Handler:       nameOfFunction(handlerFunc),
+		HandlerFunc:   handlerFunc,
+		Handlers:      len(handlers),
+		HandlersChain: handlers,
+	}
+	for _, h := range handlers[:len(handlers)-1] {
+		route.Middlewares = append(route.Middlewares, nameOfFunction(h))
+	}
+	return route
+}
+
+// nameRoute records the path of the route named name. It must be called with
+// routesMu held, see updateRoutes.
+func (engine *Engine) nameRoute(name, path string) {
+	if registered, ok := engine.routeNames[name]; ok && registered != path {
+		panic("route name '" + name + "' is already used by '" + registered + "'")
+	}
+	if engine.routeNames == nil {
+		engine.routeNames = make(map[string]string)
 	}
-	root.addRoute(path, handlers)
+	engine.routeNames[name] = path
+}
 
-	// Update maxParams
-	if paramsCount := countParams(path); paramsCount > engine.maxParams {
-		engine.maxParams = paramsCount
+// URL builds the path of the route named name, see Route.Name, with its parameters
+// given as key and value pairs. The values are escaped, the one of a catch-all
+// parameter segment by segment.
+//
+//	router.GET("/users/:id/files/*path", getFile).Name("file")
+//	router.URL("file", "id", "42", "path", "/docs/a b.txt") // "/users/42/files/docs/a%20b.txt"
+//
+// It returns an error if there is no such route, if a parameter of the route is
+// missing or doesn't satisfy its constraint, or if a parameter is unknown.
+func (engine *Engine) URL(name string, params ...string) (string, error) {
+	engine.routesMu.RLock()
+	pattern, ok := engine.routeNames[name]
+	engine.routesMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrRouteNotFound, name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("route %q: parameters must be key and value pairs", name)
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
 	}
 
-	if sectionsCount := countSections(path); sectionsCount > engine.maxSections {
-		engine.maxSections = sectionsCount
+	var sb strings.Builder
+	used := make(map[string]bool, len(values))
+	fullPath := pattern
+	for len(pattern) > 0 {
+		wildcard, i, _ := findWildcard(pattern)
+		if i < 0 {
+			sb.WriteString(pattern)
+			break
+		}
+		sb.WriteString(pattern[:i])
+		pattern = pattern[i+len(wildcard):]
+		key, constraint := parseWildcard(wildcard, fullPath)
+
+		value, ok := values[key]
+		if !ok {
+			return "", fmt.Errorf("route %q: missing parameter %q", name, key)
+		}
+		used[key] = true
+		if constraint != nil && !constraint.match(value) {
+			return "", fmt.Errorf("route %q: parameter %q doesn't match <%s>: %q", name, key, constraint.expr, value)
+		}
+		if wildcard[0] == ':' {
+			sb.WriteString(url.PathEscape(value))
+			continue
+		}
+		// the value of a catch-all parameter starts with the slash preceding it,
+		// which is already written
+		segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		sb.WriteString(strings.Join(segments, "/"))
 	}
+	for key := range values {
+		if !used[key] {
+			return "", fmt.Errorf("route %q: unknown parameter %q", name, key)
+		}
+	}
+	return sb.String(), nil
 }
 
 // Run attaches the router to a http.Server and starts listening and serving HTTP requests.
 // It is a shortcut for http.ListenAndServe(addr, router)
+// It returns the error of Validate without listening if a handler can't be injected.
 // Note: this method will block the calling goroutine indefinitely unless an error happens.
 func (engine *Engine) Run(addr ...string) (err error) {
 	defer func() { debugPrintError(err) }()
 
+	if err = engine.Validate(); err != nil {
+		return
+	}
+
 	address := resolveAddress(addr)
 	debugPrint("Listening and serving HTTP on %s\n", address)
 	err = http.ListenAndServe(address, engine.Handler())
@@ -196,11 +425,16 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c.writermem.reset(w)
 	c.reset()
 	c.Request = req
-	c.Map(c.Writer, req, c)
+	// same as c.Map(c.Writer, req, c), without allocating the variadic slice
+	c.Injector.Set(reflect.TypeOf(c.Writer), reflect.ValueOf(c.Writer))
+	c.Injector.Set(requestType, reflect.ValueOf(req))
+	c.Injector.Set(contextType, reflect.ValueOf(c))
 	c.SetParent(engine.Injector)
 
 	engine.handleHTTPRequest(c)
+	c.dispose()
 
+	c.released.Store(true)
 	engine.ctxPool.Put(c)
 }
 
@@ -217,50 +451,72 @@ func (engine *Engine) handleHTTPRequest(c *Context) {
 		rPath = cleanPath(rPath)
 	}
 
-	// Find root of the tree for the given HTTP method
-	t := engine.trees
-	for i, tl := 0, len(t); i < tl; i++ {
-		if t[i].method != httpMethod {
-			continue
-		}
-		root := t[i].root
-		// Find route in tree
-		value := root.getValue(rPath, c.params, c.skippedNodes, unescape)
+	// the routes may have been added since the context was allocated
+	table := engine.table()
+	if cap(*c.params) < int(table.maxParams) {
+		*c.params = make(Params, 0, table.maxParams)
+	}
+	if cap(*c.skippedNodes) < int(table.maxSections) {
+		*c.skippedNodes = make([]skippedNode, 0, table.maxSections)
+	}
+
+	// Find the routes of the host, then the tree for the given HTTP method
+	t := table.trees
+	if len(table.hosts) > 0 {
+		t = table.matchHost(c.Request.Host, c.params)
+		c.Params = *c.params
+	}
+	if tree := t[httpMethod]; tree != nil {
+		// Find route in the static routes, then in the tree
+		value := tree.getValue(rPath, c.params, c.skippedNodes, unescape)
 		if value.params != nil {
 			c.Params = *value.params
 		}
+		if value.versions != nil {
+			c.Writer.Header().Add("Vary", value.versions.vary)
+			if value.route = value.versions.pick(c.Request, engine.DefaultVersion); value.route != nil {
+				value.handlers = value.route.info.HandlersChain
+			} else {
+				value.handlers = nil
+			}
+		}
 		if value.handlers != nil {
 			c.handlers = value.handlers
 			c.fullPath = value.fullPath
+			c.route = value.route
+			c.plans = value.route.plans
 			c.Next()
+			c.handleErrors()
 			c.writermem.WriteHeaderNow()
 			return
 		}
-		if httpMethod != http.MethodConnect && rPath != "/" {
+		// the path of a versioned route is found whatever the version
+		if value.versions == nil && httpMethod != http.MethodConnect && rPath != "/" {
 			if value.tsr && engine.RedirectTrailingSlash {
 				redirectTrailingSlash(c)
 				return
 			}
-			if engine.RedirectFixedPath && redirectFixedPath(c, root, engine.RedirectFixedPath) {
+			if engine.RedirectFixedPath && redirectFixedPath(c, tree.root, engine.RedirectFixedPath) {
 				return
 			}
 		}
-		break
 	}
 
 	if engine.HandleMethodNotAllowed {
-		for _, tree := range engine.trees {
-			if tree.method == httpMethod {
+		for method, tree := range t {
+			if method == httpMethod {
 				continue
 			}
-			if value := tree.root.getValue(rPath, nil, c.skippedNodes, unescape); value.handlers != nil {
+			if value := tree.getValue(rPath, nil, c.skippedNodes, unescape); value.handlers != nil {
 				c.handlers = engine.allNoMethod
+				c.plans = engine.noMethodPlans
 				serveError(c, http.StatusMethodNotAllowed, default405Body)
 				return
 			}
 		}
 	}
 	c.handlers = engine.allNoRoute
+	c.plans = engine.noRoutePlans
 	serveError(c, http.StatusNotFound, default404Body)
 }
 
@@ -269,6 +525,7 @@ var mimePlain = []string{"text/plain"}
 func serveError(c *Context, code int, defaultMessage []byte) {
 	c.writermem.status = code
 	c.Next()
+	c.handleErrors()
 	if c.writermem.Written() {
 		return
 	}
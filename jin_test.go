@@ -240,6 +240,73 @@ func TestEngineRunError(t *testing.T) {
 	assert.Contains(t, err.Error(), "address already in use")
 }
 
+func handlerTest1(c *Context) {}
+func handlerTest2(c *Context) {}
+
+func TestEngineRoutes(t *testing.T) {
+	router := New()
+	router.Use(handlerTest2)
+	router.GET("/", handlerTest1)
+	group := router.Group("/users")
+	group.GET("/", handlerTest2)
+	group.GET("/:id", handlerTest1, handlerTest2)
+	router.POST("/users/:id", PassErrors(handlerTest1))
+
+	routes := router.Routes()
+	assert.Len(t, routes, 4)
+	for _, route := range routes {
+		assert.NotNil(t, route.HandlerFunc)
+		assert.Len(t, route.HandlersChain, route.Handlers)
+		route.HandlerFunc = nil
+		route.HandlersChain = nil
+		switch route.Method + " " + route.Path {
+		case "GET /users/:id":
+			assert.Equal(t, RouteInfo{
+				Method:      http.MethodGet,
+				Path:        "/users/:id",
+				Handler:     "github.com/juanjiTech/jin.handlerTest2",
+				Handlers:    3,
+				Middlewares: []string{"github.com/juanjiTech/jin.handlerTest2", "github.com/juanjiTech/jin.handlerTest1"},
+			}, route)
+		case "POST /users/:id":
+			assert.Equal(t, "github.com/juanjiTech/jin.handlerTest1", route.Handler)
+		case "GET /", "GET /users/":
+			assert.Equal(t, 2, route.Handlers)
+		default:
+			t.Errorf("unexpected route %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func TestEngineMatchRoute(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", handlerTest1)
+	router.GET("/files/*path", handlerTest2)
+
+	route, params, ok := router.MatchRoute(http.MethodGet, "/users/42")
+	assert.True(t, ok)
+	assert.Equal(t, "/users/:id", route.Path)
+	assert.Equal(t, "github.com/juanjiTech/jin.handlerTest1", route.Handler)
+	assert.Equal(t, Params{{Key: "id", Value: "42"}}, params)
+
+	route, params, ok = router.MatchRoute(http.MethodGet, "/files/a/b")
+	assert.True(t, ok)
+	assert.Equal(t, "/files/*path", route.Path)
+	assert.Equal(t, "/a/b", params.ByName("path"))
+
+	_, _, ok = router.MatchRoute(http.MethodPost, "/users/42")
+	assert.False(t, ok)
+	_, _, ok = router.MatchRoute(http.MethodGet, "/users/42/")
+	assert.False(t, ok)
+	_, _, ok = router.MatchRoute(http.MethodGet, "//users/42")
+	assert.False(t, ok)
+
+	router.RemoveExtraSlash = true
+	_, params, ok = router.MatchRoute(http.MethodGet, "//users/42")
+	assert.True(t, ok)
+	assert.Equal(t, "42", params.ByName("id"))
+}
+
 func BenchmarkAllocations(b *testing.B) {
 	engine := New()
 	engine.GET("/ping", func(c *Context) {
@@ -0,0 +1,58 @@
+package binding
+
+import (
+	"reflect"
+
+	"github.com/juanjiTech/inject/v2"
+	"github.com/juanjiTech/jin"
+)
+
+// Source values tell where a Handler binds its model from.
+const (
+	SourceBody  = "body"
+	SourceQuery = "query"
+)
+
+// Handler is the jin.HandlerFunc returned by JSON and Query. It tells where the
+// request is bound from and the type of the model, for documentation generators.
+type Handler interface {
+	inject.FastInvoker
+	// Source returns SourceBody or SourceQuery.
+	Source() string
+	// Model returns the type of the model mapped for the next handlers.
+	Model() reflect.Type
+}
+
+type jsonHandler[T any] func(*jin.Context)
+
+func (h jsonHandler[T]) Invoke(args []any) ([]reflect.Value, error) {
+	h(args[0].(*jin.Context))
+	return nil, nil
+}
+
+func (h jsonHandler[T]) Source() string {
+	return SourceBody
+}
+
+func (h jsonHandler[T]) Model() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+type queryHandler[T any] func(*jin.Context)
+
+func (h queryHandler[T]) Invoke(args []any) ([]reflect.Value, error) {
+	h(args[0].(*jin.Context))
+	return nil, nil
+}
+
+func (h queryHandler[T]) Source() string {
+	return SourceQuery
+}
+
+func (h queryHandler[T]) Model() reflect.Type {
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
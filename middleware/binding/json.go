@@ -21,9 +21,9 @@ func noSideEffectJsonUnmarshal(reader io.ReadCloser, v any) (bytes.Buffer, error
 	return buf, reader.Close()
 }
 
-func JSON[T any](model T) jin.HandlerFunc {
+func JSON[T any](model T) Handler {
 	_ = model // to avoid unused variable warning
-	return func(ctx *jin.Context) {
+	return jsonHandler[T](func(ctx *jin.Context) {
 		var t T
 		r := ctx.Request
 		if r.Body != nil {
@@ -32,7 +32,7 @@ func JSON[T any](model T) jin.HandlerFunc {
 				// EOF means empty body, which is not an error in this context.
 				// We should continue to the next handler.
 				if !errors.Is(err, io.EOF) {
-					ctx.Error(err)
+					_ = ctx.Error(err).SetType(jin.ErrorTypeBind)
 					ctx.Abort()
 					return
 				}
@@ -43,5 +43,5 @@ func JSON[T any](model T) jin.HandlerFunc {
 			r.Body = io.NopCloser(&buf)
 		}
 		ctx.Next()
-	}
+	})
 }
@@ -89,3 +89,18 @@ func TestNoSideEffectJsonUnmarshalError(t *testing.T) {
 	_, err := noSideEffectJsonUnmarshal(io.NopCloser(reader), &v)
 	assert.Error(t, err)
 }
+
+func TestJSONBindingErrorType(t *testing.T) {
+	e := jin.New()
+	var errs []string
+	e.POST("/", func(c *jin.Context) {
+		c.Next()
+		errs = c.Errors.ByType(jin.ErrorTypeBind).Errors()
+	}, JSON(ExampleReq{}), func(c *jin.Context) {})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name": 1}`))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
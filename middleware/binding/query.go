@@ -106,7 +106,7 @@ func recursiveSetting(typer reflect.Type, queries url.Values, prefix string) (va
 	return valueT
 }
 
-func Query[T any](model T) jin.HandlerFunc {
+func Query[T any](model T) Handler {
 	_ = model // to avoid unused variable warning
 	typer := reflect.TypeOf(model)
 	// only support struct
@@ -116,9 +116,9 @@ func Query[T any](model T) jin.HandlerFunc {
 	if typer.Kind() != reflect.Struct {
 		panic("model must be a struct")
 	}
-	return func(ctx *jin.Context) {
+	return queryHandler[T](func(ctx *jin.Context) {
 		queries := ctx.Request.URL.Query()
 		valueT := recursiveSetting(typer, queries, "")
 		ctx.Map(valueT.Interface())
-	}
+	})
 }
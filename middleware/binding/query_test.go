@@ -174,3 +174,13 @@ func TestQueryBindingComprehensiveTypes(t *testing.T) {
 
 	assert.Equal(t, expected, resp)
 }
+
+func TestHandlerModel(t *testing.T) {
+	var h Handler = Query(&ExampleQuery{})
+	assert.Equal(t, SourceQuery, h.Source())
+	assert.Equal(t, reflect.TypeOf(ExampleQuery{}), h.Model())
+
+	h = JSON(ExampleReq{})
+	assert.Equal(t, SourceBody, h.Source())
+	assert.Equal(t, reflect.TypeOf(ExampleReq{}), h.Model())
+}
@@ -0,0 +1,420 @@
+// Package openapi generates an OpenAPI 3.1 document from the routes of a jin.Engine.
+//
+// The request schemas are taken from the models of the binding.JSON and binding.Query
+// handlers and from the request types of jin.Typed handlers, the response schemas
+// from the response types of jin.Typed handlers. The summary, description and
+// deprecation of an operation are read from the "summary", "description" and
+// "deprecated" metadata of the route, see jin.Route.Meta, its tags from the tags of
+// the route and its operationId from the name of the route.
+//
+// A document describes a method on a path once, while the routes of several hosts or
+// API versions can share it. Generate documents the routes a request to no particular
+// host and asking for no version is handled by, GenerateScope the ones of a host and
+// a version, see Scope.
+package openapi
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/juanjiTech/jin"
+	"github.com/juanjiTech/jin/middleware/binding"
+	"github.com/juanjiTech/jin/render"
+)
+
+// Version is the version of the OpenAPI specification of the generated documents.
+const Version = "3.1.0"
+
+// Metadata keys read from the routes.
+const (
+	MetaSummary     = "summary"
+	MetaDescription = "description"
+	MetaDeprecated  = "deprecated"
+)
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       Info                 `json:"info" yaml:"info"`
+	Paths      map[string]*PathItem `json:"paths" yaml:"paths"`
+	Components *Components          `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path.
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty" yaml:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty" yaml:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty" yaml:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty" yaml:"trace,omitempty"`
+}
+
+// Operation describes an API operation, a method on a path.
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses" yaml:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// Parameter describes a path, query or header parameter of an operation.
+type Parameter struct {
+	Name     string  `json:"name" yaml:"name"`
+	In       string  `json:"in" yaml:"in"`
+	Required bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// RequestBody describes the body of the requests of an operation.
+type RequestBody struct {
+	Content  map[string]*MediaType `json:"content" yaml:"content"`
+	Required bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// MediaType holds the schema of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// Components holds the schemas referenced by the document.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
+// Scope selects the routes documented by GenerateScope and ServeScope.
+type Scope struct {
+	// Host is the host pattern of the routes, as given to jin.Engine.Host, or "" for
+	// the routes registered outside of the Host groups.
+	Host string
+	// APIVersion is the API version the routes are documented for, see
+	// jin.RouterGroup.Version. A method and path is documented with the route handling
+	// the requests asking for this version, or for no version if it is "", as picked
+	// by jin.PickVersion. It is left out if no route handles them.
+	APIVersion string
+}
+
+// Generate returns the OpenAPI document of the routes registered in engine outside
+// of the Host groups, as served to the requests asking for no API version.
+func Generate(engine *jin.Engine, info Info) *Document {
+	return GenerateScope(engine, info, Scope{})
+}
+
+// GenerateScope returns the OpenAPI document of the routes registered in engine for
+// the host and API version of scope.
+func GenerateScope(engine *jin.Engine, info Info, scope Scope) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+	}
+	schemas := newSchemaRegistry()
+	for _, route := range scopeRoutes(engine, scope) {
+		op := operation(route, schemas)
+		if op == nil {
+			continue
+		}
+		p := pathPattern(route.Path)
+		item := doc.Paths[p]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[p] = item
+		}
+		item.set(route.Method, op)
+	}
+	if len(schemas.schemas) > 0 {
+		doc.Components = &Components{Schemas: schemas.schemas}
+	}
+	return doc
+}
+
+// Serve registers a GET route at relativePath which renders the document of the
+// routes of engine, see Generate, as YAML if the path has a .yaml or .yml extension
+// and as JSON otherwise. The document is generated on each request, so it also covers
+// the routes registered after Serve.
+func Serve(engine *jin.Engine, relativePath string, info Info) *jin.Route {
+	return ServeScope(engine, relativePath, info, Scope{})
+}
+
+// ServeScope is Serve for the routes of scope, see GenerateScope.
+//
+//	openapi.ServeScope(router, "/openapi.v2.json", info, openapi.Scope{APIVersion: "2"})
+func ServeScope(engine *jin.Engine, relativePath string, info Info, scope Scope) *jin.Route {
+	yaml := false
+	switch path.Ext(relativePath) {
+	case ".yaml", ".yml":
+		yaml = true
+	}
+	return engine.GET(relativePath, func(c *jin.Context) {
+		doc := GenerateScope(engine, info, scope)
+		if yaml {
+			c.Render(http.StatusOK, render.YAML{Data: doc})
+			return
+		}
+		c.Render(http.StatusOK, render.JSON{Data: doc})
+	})
+}
+
+// scopeRoutes returns the routes of engine in scope, one per method and path.
+func scopeRoutes(engine *jin.Engine, scope Scope) []jin.RouteInfo {
+	host := strings.ToLower(strings.TrimSuffix(scope.Host, "."))
+	var keys [][2]string
+	versions := make(map[[2]string][]jin.RouteInfo)
+	for _, route := range engine.Routes() {
+		if route.Host != host {
+			continue
+		}
+		key := [2]string{route.Method, route.Path}
+		if versions[key] == nil {
+			keys = append(keys, key)
+		}
+		versions[key] = append(versions[key], route)
+	}
+
+	routes := make([]jin.RouteInfo, 0, len(keys))
+	for _, key := range keys {
+		if route, ok := jin.PickVersion(versions[key], scope.APIVersion, engine.DefaultVersion); ok {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+func (item *PathItem) set(method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodOptions:
+		item.Options = op
+	case http.MethodHead:
+		item.Head = op
+	case http.MethodPatch:
+		item.Patch = op
+	case http.MethodTrace:
+		item.Trace = op
+	}
+}
+
+// pathPattern converts the :param, :param<constraint> and *catchAll segments of a
+// route path to {param}.
+func pathPattern(p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		if name, _, ok := paramName(segment); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// paramName returns the name of the param of a path segment and its constraint, if
+// it has one.
+func paramName(segment string) (name, constraint string, ok bool) {
+	if len(segment) < 2 || (segment[0] != ':' && segment[0] != '*') {
+		return "", "", false
+	}
+	name = segment[1:]
+	if i := strings.IndexByte(name, '<'); i > 0 && name[len(name)-1] == '>' {
+		name, constraint = name[:i], name[i+1:len(name)-1]
+	}
+	return name, constraint, true
+}
+
+// paramSchema returns the schema of the values satisfying a param constraint.
+func paramSchema(constraint string) *Schema {
+	switch constraint {
+	case "":
+		return &Schema{Type: "string"}
+	case jin.ConstraintInt:
+		return &Schema{Type: "integer"}
+	case jin.ConstraintUUID:
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	return &Schema{Type: "string", Pattern: "^(?:" + constraint + ")$"}
+}
+
+// operation returns the Operation of a route, or nil for the methods OpenAPI doesn't
+// describe, like CONNECT.
+func operation(route jin.RouteInfo, schemas *schemaRegistry) *Operation {
+	switch route.Method {
+	case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+		http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace:
+	default:
+		return nil
+	}
+
+	op := &Operation{
+		Tags:        route.Tags,
+		OperationID: route.Name,
+		Responses:   make(map[string]*Response),
+	}
+	op.Summary, _ = route.Meta[MetaSummary].(string)
+	op.Description, _ = route.Meta[MetaDescription].(string)
+	switch deprecated := route.Meta[MetaDeprecated].(type) {
+	case nil:
+	case bool:
+		op.Deprecated = deprecated
+	default:
+		// like a deprecation date
+		op.Deprecated = true
+	}
+
+	for _, segment := range strings.Split(route.Path, "/") {
+		if name, constraint, ok := paramName(segment); ok {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   paramSchema(constraint),
+			})
+		}
+	}
+
+	hasBody := route.Method != http.MethodGet && route.Method != http.MethodHead
+	var response *Response
+	for _, h := range route.HandlersChain {
+		switch h := h.(type) {
+		case binding.Handler:
+			switch h.Source() {
+			case binding.SourceBody:
+				if hasBody {
+					op.RequestBody = jsonBody(schemas.schema(h.Model()))
+				}
+			case binding.SourceQuery:
+				op.addParameters(queryParameters(h.Model(), "", schemas))
+			}
+		case jin.TypedHandler:
+			params, body := typedRequest(h.RequestType(), schemas)
+			op.addParameters(params)
+			if body != nil && hasBody {
+				op.RequestBody = jsonBody(body)
+			}
+			response = &Response{
+				Description: http.StatusText(http.StatusOK),
+				Content:     map[string]*MediaType{jin.MIMEJSON: {Schema: schemas.schema(h.ResponseType())}},
+			}
+		}
+	}
+	if response == nil {
+		response = &Response{Description: http.StatusText(http.StatusOK)}
+	}
+	op.Responses["200"] = response
+	return op
+}
+
+func (op *Operation) addParameters(params []*Parameter) {
+	for _, param := range params {
+		replaced := false
+		for i, p := range op.Parameters {
+			if p.Name == param.Name && p.In == param.In {
+				op.Parameters[i] = param
+				replaced = true
+			}
+		}
+		if !replaced {
+			op.Parameters = append(op.Parameters, param)
+		}
+	}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{Content: map[string]*MediaType{jin.MIMEJSON: {Schema: schema}}}
+}
+
+// queryParameters returns the parameters of a binding.Query model, named after the
+// `query` tags with nested structs prefixed according to binding.DefaultQueryFormat.
+func queryParameters(t reflect.Type, prefix string, schemas *schemaRegistry) []*Parameter {
+	var params []*Parameter
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := field.Tag.Lookup("query")
+		if field.Anonymous || !ok {
+			continue
+		}
+		if prefix != "" {
+			key = strings.ReplaceAll(strings.ReplaceAll(binding.DefaultQueryFormat, "PREFIX", prefix), "CURRENT", key)
+		}
+		if field.Type.Kind() == reflect.Struct {
+			params = append(params, queryParameters(field.Type, key, schemas)...)
+			continue
+		}
+		params = append(params, &Parameter{Name: key, In: "query", Schema: schemas.schema(field.Type)})
+	}
+	return params
+}
+
+// typedRequest returns the parameters and the body schema of the request type of a
+// jin.Typed handler, see jin.Typed for the tags.
+func typedRequest(t reflect.Type, schemas *schemaRegistry) ([]*Parameter, *Schema) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, schemas.schema(t)
+	}
+
+	var params []*Parameter
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if key, ok := field.Tag.Lookup("uri"); ok && key != "-" {
+			params = append(params, &Parameter{Name: key, In: "path", Required: true, Schema: schemas.schema(field.Type)})
+			continue
+		}
+		if key, ok := field.Tag.Lookup("query"); ok && key != "-" {
+			params = append(params, &Parameter{Name: key, In: "query", Schema: schemas.schema(field.Type)})
+			continue
+		}
+		if key, ok := field.Tag.Lookup("header"); ok && key != "-" {
+			params = append(params, &Parameter{Name: key, In: "header", Schema: schemas.schema(field.Type)})
+			continue
+		}
+		if name, required, ok := jsonField(field); ok {
+			body.Properties[name] = schemas.schema(field.Type)
+			if required {
+				body.Required = append(body.Required, name)
+			}
+		}
+	}
+	if len(body.Properties) == 0 {
+		return params, nil
+	}
+	sort.Strings(body.Required)
+	return params, body
+}
@@ -0,0 +1,219 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/juanjiTech/jin"
+	"github.com/juanjiTech/jin/middleware/binding"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type createUser struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email,omitempty"`
+	Tags  []string `json:"tags"`
+	Skip  string   `json:"-"`
+}
+
+type listUsers struct {
+	Page   int `query:"page"`
+	Filter struct {
+		Name string `query:"name"`
+	} `query:"filter"`
+}
+
+type getUser struct {
+	OrgID string `uri:"org"`
+	ID    int64  `uri:"id"`
+	Token string `header:"X-Token"`
+}
+
+type user struct {
+	ID      int64     `json:"id"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	Manager *user     `json:"manager"`
+}
+
+func TestGenerate(t *testing.T) {
+	router := jin.New()
+	router.POST("/users", binding.JSON(createUser{}), func(c *jin.Context, req createUser) {}).
+		Name("createUser").
+		Meta(MetaSummary, "Create a user").
+		Tags("users")
+	router.GET("/users", binding.Query(listUsers{}), func(c *jin.Context, req listUsers) {}).
+		Meta(MetaDeprecated, "2026-01-01")
+	router.GET("/orgs/:org/users/:id", jin.Typed(func(c *jin.Context, req getUser) (user, error) {
+		return user{}, nil
+	}))
+	router.GET("/files/*path", func(c *jin.Context) {})
+	router.Handle(http.MethodConnect, "/tunnel", func(c *jin.Context) {})
+
+	doc := Generate(router, Info{Title: "Users", Version: "1.0.0"})
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, "Users", doc.Info.Title)
+	assert.Len(t, doc.Paths, 3)
+	assert.NotContains(t, doc.Paths, "/tunnel")
+
+	create := doc.Paths["/users"].Post
+	if assert.NotNil(t, create) {
+		assert.Equal(t, "createUser", create.OperationID)
+		assert.Equal(t, "Create a user", create.Summary)
+		assert.Equal(t, []string{"users"}, create.Tags)
+		assert.False(t, create.Deprecated)
+		if assert.NotNil(t, create.RequestBody) {
+			schema := create.RequestBody.Content[jin.MIMEJSON].Schema
+			assert.Equal(t, "#/components/schemas/createUser", schema.Ref)
+		}
+		assert.Equal(t, &Response{Description: "OK"}, create.Responses["200"])
+	}
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":  {Type: "string"},
+			"email": {Type: "string"},
+			"tags":  {Type: "array", Items: &Schema{Type: "string"}},
+		},
+		Required: []string{"name", "tags"},
+	}, doc.Components.Schemas["createUser"])
+
+	list := doc.Paths["/users"].Get
+	if assert.NotNil(t, list) {
+		assert.True(t, list.Deprecated)
+		assert.Nil(t, list.RequestBody)
+		assert.Equal(t, []*Parameter{
+			{Name: "page", In: "query", Schema: &Schema{Type: "integer"}},
+			{Name: "filter.name", In: "query", Schema: &Schema{Type: "string"}},
+		}, list.Parameters)
+	}
+
+	get := doc.Paths["/orgs/{org}/users/{id}"].Get
+	if assert.NotNil(t, get) {
+		assert.Equal(t, []*Parameter{
+			{Name: "org", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Format: "int64"}},
+			{Name: "X-Token", In: "header", Schema: &Schema{Type: "string"}},
+		}, get.Parameters)
+		assert.Nil(t, get.RequestBody)
+		assert.Equal(t, "#/components/schemas/user", get.Responses["200"].Content[jin.MIMEJSON].Schema.Ref)
+	}
+	assert.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":      {Type: "integer", Format: "int64"},
+			"name":    {Type: "string"},
+			"created": {Type: "string", Format: "date-time"},
+			"manager": {Ref: "#/components/schemas/user"},
+		},
+		Required: []string{"created", "id", "name"},
+	}, doc.Components.Schemas["user"])
+
+	files := doc.Paths["/files/{path}"].Get
+	if assert.NotNil(t, files) {
+		assert.Equal(t, []*Parameter{
+			{Name: "path", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		}, files.Parameters)
+	}
+}
+
+// otherCreateUser lets TestSchemaNameCollision use createUser next to a local type
+// of the same name.
+type otherCreateUser = createUser
+
+func TestSchemaNameCollision(t *testing.T) {
+	type createUser struct {
+		ID int `json:"id"`
+	}
+	schemas := newSchemaRegistry()
+	first := schemas.schema(reflect.TypeOf(createUser{}))
+	assert.Equal(t, "#/components/schemas/createUser", first.Ref)
+	second := schemas.schema(reflect.TypeOf(&createUser{}))
+	assert.Equal(t, first, second)
+
+	other := schemas.schema(reflect.TypeOf([]otherCreateUser{}))
+	assert.Equal(t, "#/components/schemas/github.com_juanjiTech_jin_middleware_openapi.createUser", other.Items.Ref)
+	assert.Len(t, schemas.schemas, 2)
+}
+
+func TestServe(t *testing.T) {
+	router := jin.New()
+	Serve(router, "/openapi.json", Info{Title: "API", Version: "1"})
+	Serve(router, "/openapi.yaml", Info{Title: "API", Version: "1"})
+	router.GET("/ping", func(c *jin.Context) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var doc Document
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Contains(t, doc.Paths, "/ping")
+	assert.Contains(t, doc.Paths, "/openapi.yaml")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	doc = Document{}
+	assert.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "API", doc.Info.Title)
+	assert.Contains(t, doc.Paths, "/ping")
+}
+
+func TestGenerateConstrainedParams(t *testing.T) {
+	router := jin.New()
+	router.GET("/items/:id<int>/:slug<[a-z-]+>/:ref<uuid>", func(c *jin.Context) {})
+
+	doc := Generate(router, Info{Title: "Items", Version: "1"})
+	item := doc.Paths["/items/{id}/{slug}/{ref}"]
+	if assert.NotNil(t, item) && assert.NotNil(t, item.Get) {
+		assert.Equal(t, []*Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer"}},
+			{Name: "slug", In: "path", Required: true, Schema: &Schema{Type: "string", Pattern: "^(?:[a-z-]+)$"}},
+			{Name: "ref", In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}},
+		}, item.Get.Parameters)
+	}
+}
+
+func TestGenerateScope(t *testing.T) {
+	router := jin.New()
+	selector := jin.HeaderVersion("API-Version")
+	router.GET("/users", func(c *jin.Context) {}).Name("listUsers")
+	router.Version("1", selector).GET("/users", func(c *jin.Context) {}).Name("listUsersV1")
+	router.Version("2", selector).GET("/users", func(c *jin.Context) {}).Name("listUsersV2")
+	router.Version("2", selector).GET("/items", func(c *jin.Context) {}).Name("listItemsV2")
+	router.Host("API.example.com").GET("/users", func(c *jin.Context) {}).Name("listAPIUsers")
+
+	operationIDs := func(doc *Document) map[string]string {
+		ids := map[string]string{}
+		for p, item := range doc.Paths {
+			ids[p] = item.Get.OperationID
+		}
+		return ids
+	}
+	info := Info{Title: "Users", Version: "1"}
+	// /items is only served to the requests asking for version 2 or above
+	assert.Equal(t, map[string]string{"/users": "listUsers"},
+		operationIDs(Generate(router, info)))
+	assert.Equal(t, map[string]string{"/users": "listUsersV2", "/items": "listItemsV2"},
+		operationIDs(GenerateScope(router, info, Scope{APIVersion: "v2.0"})))
+	assert.Equal(t, map[string]string{"/users": "listUsersV1"},
+		operationIDs(GenerateScope(router, info, Scope{APIVersion: "1.5"})))
+	assert.Equal(t, map[string]string{"/users": "listAPIUsers"},
+		operationIDs(GenerateScope(router, info, Scope{Host: "api.example.com"})))
+
+	router.DefaultVersion = "1"
+	assert.Equal(t, "listUsersV1", Generate(router, info).Paths["/users"].Get.OperationID)
+
+	ServeScope(router, "/openapi.v2.json", info, Scope{APIVersion: "2"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.v2.json", nil))
+	var doc Document
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "listUsersV2", doc.Paths["/users"].Get.OperationID)
+}
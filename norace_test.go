@@ -0,0 +1,5 @@
+//go:build !race
+
+package jin
+
+const raceEnabled = false
@@ -0,0 +1,117 @@
+package jin
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/juanjiTech/inject/v2"
+)
+
+var (
+	contextType = reflect.TypeOf((*Context)(nil))
+	requestType = reflect.TypeOf((*http.Request)(nil))
+)
+
+// handlerPlan holds what Context.Next needs to invoke a handler. It is computed once
+// when the route is added, so that serving a request doesn't reflect on the handlers.
+type handlerPlan struct {
+	// handler is nil for the nil handlers of a chain, which are skipped.
+	handler HandlerFunc
+	fn      reflect.Value
+	fnType  reflect.Type
+	// fast is set if the handler implements inject.FastInvoker.
+	fast inject.FastInvoker
+	in   []reflect.Type
+	out  []reflect.Type
+	// passErrors is set for the handlers wrapped by PassErrors.
+	passErrors bool
+	// returnsError reports whether the last return value is an error.
+	returnsError bool
+}
+
+func newHandlerPlan(h HandlerFunc) handlerPlan {
+	h, passErrors := unwrapHandler(h)
+	if h == nil {
+		return handlerPlan{}
+	}
+	fnType := reflect.TypeOf(h)
+	plan := handlerPlan{
+		handler:    h,
+		fn:         reflect.ValueOf(h),
+		fnType:     fnType,
+		passErrors: passErrors,
+	}
+	plan.fast, _ = h.(inject.FastInvoker)
+	if fnType.Kind() != reflect.Func {
+		// Context.Invoke reports it
+		return plan
+	}
+	if n := fnType.NumIn(); n > 0 {
+		plan.in = make([]reflect.Type, n)
+		for i := range plan.in {
+			plan.in[i] = fnType.In(i)
+		}
+	}
+	if n := fnType.NumOut(); n > 0 {
+		plan.out = make([]reflect.Type, n)
+		for i := range plan.out {
+			plan.out[i] = fnType.Out(i)
+		}
+		plan.returnsError = plan.out[n-1] == errorType
+	}
+	return plan
+}
+
+func newChainPlan(handlers HandlersChain) []handlerPlan {
+	plans := make([]handlerPlan, len(handlers))
+	for i, h := range handlers {
+		plans[i] = newHandlerPlan(h)
+	}
+	return plans
+}
+
+// handlerPlan returns the plan of the i-th handler. It is computed on the spot if the
+// handlers weren't planned, like when they are set by hand.
+func (c *Context) handlerPlan(i int8) *handlerPlan {
+	if len(c.plans) == len(c.handlers) {
+		return &c.plans[i]
+	}
+	plan := newHandlerPlan(c.handlers[i])
+	return &plan
+}
+
+// invoke calls the handler of plan with its arguments resolved by the injector.
+func (c *Context) invoke(plan *handlerPlan) ([]reflect.Value, error) {
+	inj, ok := c.Injector.(*requestInjector)
+	if !ok || plan.fn.Kind() != reflect.Func {
+		return c.Invoke(plan.handler)
+	}
+
+	if plan.fast != nil {
+		// the arguments are passed on by the invoker before the handler runs, so the
+		// buffer is free again when the handler calls Next
+		args := c.args[:0]
+		for _, t := range plan.in {
+			val, err := inj.arg(t)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, val.Interface())
+		}
+		c.args = args
+		return plan.fast.Invoke(args)
+	}
+
+	var in []reflect.Value
+	if len(plan.in) > 0 {
+		in = make([]reflect.Value, len(plan.in))
+		for i, t := range plan.in {
+			val, err := inj.arg(t)
+			if err != nil {
+				return nil, err
+			}
+			in[i] = val
+		}
+	}
+	return plan.fn.Call(in), nil
+}
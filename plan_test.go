@@ -0,0 +1,69 @@
+package jin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandlerPlan(t *testing.T) {
+	plan := newHandlerPlan(nil)
+	assert.Nil(t, plan.handler)
+
+	plan = newHandlerPlan(fastInvokeWarpHandler(func(c *Context) {}))
+	assert.NotNil(t, plan.fast)
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(&Context{})}, plan.in)
+	assert.Empty(t, plan.out)
+	assert.False(t, plan.returnsError)
+
+	plan = newHandlerPlan(PassErrors(func(r *http.Request) (string, error) { return "", nil }))
+	assert.Nil(t, plan.fast)
+	assert.True(t, plan.passErrors)
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(&http.Request{})}, plan.in)
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(""), errorType}, plan.out)
+	assert.True(t, plan.returnsError)
+}
+
+func TestEngineAddRoutePlans(t *testing.T) {
+	router := New()
+	router.Use(func(c *Context) {})
+	router.GET("/", func() error { return errors.New("oops") }, func(c *Context) {})
+	router.NoRoute(func() int { return 0 })
+
+	route := router.table().trees.get(http.MethodGet).route
+	if assert.Len(t, route.plans, 3) {
+		assert.NotNil(t, route.plans[0].fast)
+		assert.True(t, route.plans[1].returnsError)
+	}
+	assert.Len(t, router.noRoutePlans, 2)
+	assert.Len(t, router.noMethodPlans, 1)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestContextNextAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	router := New()
+	router.Use(func(c *Context) {
+		c.Next()
+	})
+	router.GET("/", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	allocs := testing.AllocsPerRun(100, func() {
+		router.ServeHTTP(w, req)
+	})
+	assert.Zero(t, allocs)
+}
@@ -0,0 +1,480 @@
+package jin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/juanjiTech/inject/v2"
+)
+
+// ErrProviderCycle is returned when providers depend on each other.
+var ErrProviderCycle = errors.New("provider dependency cycle")
+
+// Lifetime tells how long the value built by a provider lives.
+type Lifetime uint8
+
+const (
+	// Scoped providers run at most once per request, their value is shared by
+	// the handlers of the request and closed when the request ends if it
+	// implements io.Closer.
+	Scoped Lifetime = iota
+	// Singleton providers run at most once per engine, their value is shared by
+	// all the requests. They can't depend on request-scoped values.
+	Singleton
+	// Transient providers run every time their type is injected.
+	Transient
+)
+
+// String returns the name of the lifetime.
+func (l Lifetime) String() string {
+	switch l {
+	case Scoped:
+		return "scoped"
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	}
+	return fmt.Sprintf("Lifetime(%d)", uint8(l))
+}
+
+// requestScopedTypes are mapped by the engine for every request.
+var requestScopedTypes = []reflect.Type{
+	contextType,
+	requestType,
+	reflect.TypeOf((*http.ResponseWriter)(nil)).Elem(),
+	reflect.TypeOf((*ResponseWriter)(nil)).Elem(),
+}
+
+// provider is a constructor registered with Engine.Provide.
+type provider struct {
+	fn       HandlerFunc
+	out      reflect.Type
+	hasError bool
+	lifetime Lifetime
+
+	// mu guards value, the cached value of a singleton.
+	mu    sync.Mutex
+	value reflect.Value
+}
+
+// ProviderError is recorded in Context.Errors when a provider registered with
+// Engine.Provide fails, the rest of the chain is aborted.
+type ProviderError struct {
+	// Type is the type the provider constructs.
+	Type reflect.Type
+	// Provider is the name of the provider function.
+	Provider string
+	Err      error
+}
+
+// Error implements the error interface.
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("unable to provide %v with %s: %v", e.Type, e.Provider, e.Err)
+}
+
+// Unwrap returns the error of the provider.
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Provide registers constructors of request-scoped dependencies. A constructor
+// is a function returning the dependency, optionally followed by an error, and
+// its parameters are injected like the ones of a handler, including other
+// provided types. It is only called the first time a handler of the request
+// asks for the type it returns, and the result is reused for the rest of the
+// request. If it returns an error, the error is recorded in Context.Errors as a
+// *ProviderError and the chain is aborted.
+//
+//	engine.Map(db)
+//	engine.Provide(func(c *jin.Context, db *sql.DB) (*UserRepo, error) {
+//	    return NewUserRepo(c, db)
+//	})
+//
+// It is a shortcut for engine.ProvideWith(jin.Scoped, constructors...).
+func (engine *Engine) Provide(constructors ...HandlerFunc) {
+	engine.ProvideWith(Scoped, constructors...)
+}
+
+// ProvideWith registers constructors with the given lifetime, see Provide.
+// It panics if a singleton ends up depending on a request-scoped value, either
+// directly or through other providers, as it would outlive that value.
+func (engine *Engine) ProvideWith(lifetime Lifetime, constructors ...HandlerFunc) {
+	if lifetime > Transient {
+		panic("unknown provider lifetime " + lifetime.String())
+	}
+	for _, fn := range constructors {
+		p := newProvider(fn)
+		p.lifetime = lifetime
+		if engine.providers == nil {
+			engine.providers = make(map[reflect.Type]*provider)
+		}
+		if _, ok := engine.providers[p.out]; ok {
+			panic(fmt.Sprintf("a provider is already registered for %v", p.out))
+		}
+		engine.providers[p.out] = p
+	}
+	// a new provider may also be the scoped dependency of a registered singleton
+	for _, p := range engine.providers {
+		if p.lifetime != Singleton {
+			continue
+		}
+		if path := engine.scopedDependency(p, nil); path != nil {
+			panic(fmt.Sprintf("singleton provider %s depends on request-scoped %s",
+				nameOfFunction(p.fn), formatTypePath(path)))
+		}
+	}
+}
+
+func newProvider(fn HandlerFunc) *provider {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		panic(fmt.Sprintf("provider must be a callable function, but got %T", fn))
+	}
+	p := &provider{fn: fastInvokeWarpHandler(fn)}
+	switch fnType.NumOut() {
+	case 2:
+		if fnType.Out(1) != errorType {
+			panic(fmt.Sprintf("the second return value of provider %s must be an error", nameOfFunction(fn)))
+		}
+		p.hasError = true
+		fallthrough
+	case 1:
+		p.out = fnType.Out(0)
+	default:
+		panic(fmt.Sprintf("provider %s must return a value, optionally followed by an error", nameOfFunction(fn)))
+	}
+	if p.out == errorType {
+		panic(fmt.Sprintf("provider %s can not provide an error", nameOfFunction(fn)))
+	}
+	return p
+}
+
+// scopedDependency returns the path from the parameters of p to a request-scoped
+// type, or nil if p only depends on values living as long as the engine.
+func (engine *Engine) scopedDependency(p *provider, visited map[*provider]bool) []reflect.Type {
+	if visited == nil {
+		visited = make(map[*provider]bool)
+	}
+	if visited[p] {
+		return nil
+	}
+	visited[p] = true
+
+	fnType := reflect.TypeOf(p.fn)
+	for i := 0; i < fnType.NumIn(); i++ {
+		in := fnType.In(i)
+		if engine.Injector.Value(in).IsValid() {
+			continue
+		}
+		for _, scoped := range requestScopedTypes {
+			if in == scoped {
+				return []reflect.Type{in}
+			}
+		}
+		dep := engine.providers[in]
+		if dep == nil {
+			continue
+		}
+		if dep.lifetime == Scoped {
+			return []reflect.Type{in}
+		}
+		if path := engine.scopedDependency(dep, visited); path != nil {
+			return append([]reflect.Type{in}, path...)
+		}
+	}
+	return nil
+}
+
+func formatTypePath(path []reflect.Type) string {
+	names := make([]string, len(path))
+	for i, t := range path {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// construct calls the provider with its arguments resolved by resolveArg.
+func (p *provider) construct(resolveArg func(reflect.Type) (reflect.Value, error)) (reflect.Value, error) {
+	values, err := invokeWith(p.fn, resolveArg)
+	if err != nil {
+		var providerErr *ProviderError
+		if errors.As(err, &providerErr) {
+			return reflect.Value{}, err
+		}
+		return reflect.Value{}, p.error(err)
+	}
+	if p.hasError {
+		if err, _ := values[1].Interface().(error); err != nil {
+			return reflect.Value{}, p.error(err)
+		}
+	}
+	return values[0], nil
+}
+
+func (p *provider) error(err error) *ProviderError {
+	return &ProviderError{Type: p.out, Provider: nameOfFunction(p.fn), Err: err}
+}
+
+// singleton returns the value of a singleton provider, constructing it on first use.
+func (engine *Engine) singleton(p *provider, resolving []reflect.Type) (reflect.Value, error) {
+	for _, t := range resolving {
+		if t == p.out {
+			return reflect.Value{}, p.error(ErrProviderCycle)
+		}
+	}
+	resolving = append(resolving, p.out)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.value.IsValid() {
+		return p.value, nil
+	}
+	val, err := p.construct(func(t reflect.Type) (reflect.Value, error) {
+		return engine.singletonArg(t, resolving)
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	p.value = val
+	return val, nil
+}
+
+// singletonArg resolves a dependency of a singleton with the engine injector and
+// the other singleton and transient providers.
+func (engine *Engine) singletonArg(t reflect.Type, resolving []reflect.Type) (reflect.Value, error) {
+	if val := engine.Injector.Value(t); val.IsValid() {
+		return val, nil
+	}
+	p := engine.providers[t]
+	if p == nil || p.lifetime == Scoped {
+		return reflect.Value{}, fmt.Errorf("%w: %v", inject.ErrValueNotFound, t)
+	}
+	if p.lifetime == Singleton {
+		return engine.singleton(p, resolving)
+	}
+	for _, r := range resolving {
+		if r == t {
+			return reflect.Value{}, p.error(ErrProviderCycle)
+		}
+	}
+	resolving = append(resolving, t)
+	return p.construct(func(t reflect.Type) (reflect.Value, error) {
+		return engine.singletonArg(t, resolving)
+	})
+}
+
+// requestInjector is the Injector of a Context. It resolves the types which are
+// neither mapped in the request nor in the engine with the providers of the engine.
+type requestInjector struct {
+	inject.Injector
+	c         *Context
+	resolving []reflect.Type
+	// mapped records the values mapped in the injector to give the copies of the
+	// context a snapshot of them.
+	mapped []mappedValue
+	// outer is the parent of the injector.
+	outer inject.Injector
+	// scoped holds the values built by scoped providers, in construction order.
+	scoped []reflect.Value
+	// holders counts the contexts using the scoped values, the one of the request
+	// and its copies. The values are closed when it drops to zero.
+	holders atomic.Int32
+	// parent is the injector of the context a copy was made from, released along
+	// with the copy.
+	parent *requestInjector
+}
+
+type mappedValue struct {
+	t   reflect.Type
+	val reflect.Value
+}
+
+var _ inject.Injector = (*requestInjector)(nil)
+
+func newRequestInjector(c *Context) *requestInjector {
+	inj := &requestInjector{Injector: inject.New(), c: c}
+	inj.holders.Store(1)
+	return inj
+}
+
+// child returns the injector of the copy c of the context of inj. It looks up a
+// snapshot of the values mapped or already built in inj when child is called, as
+// the request may keep mapping values at the same time, and builds the missing
+// scoped values in its own scope. inj is held until the child is released.
+func (inj *requestInjector) child(c *Context) *requestInjector {
+	inj.holders.Add(1)
+	snapshot := inject.New()
+	for _, m := range inj.mapped {
+		snapshot.Set(m.t, m.val)
+	}
+	snapshot.SetParent(inj.outer)
+	child := newRequestInjector(c)
+	child.SetParent(snapshot)
+	child.parent = inj
+	return child
+}
+
+// Reset resets the mapped values and the parent.
+func (inj *requestInjector) Reset() {
+	inj.Injector.Reset()
+	clear(inj.mapped)
+	inj.mapped = inj.mapped[:0]
+	inj.outer = nil
+	inj.resolving = inj.resolving[:0]
+	inj.scoped = inj.scoped[:0]
+	inj.holders.Store(1)
+}
+
+// SetParent sets the parent of the injector.
+func (inj *requestInjector) SetParent(parent inject.Injector) inject.Injector {
+	inj.Injector.SetParent(parent)
+	inj.outer = parent
+	return inj
+}
+
+// Map maps the values based on their type, see inject.TypeMapper.
+func (inj *requestInjector) Map(values ...any) inject.TypeMapper {
+	for _, val := range values {
+		inj.Set(reflect.TypeOf(val), reflect.ValueOf(val))
+	}
+	return inj
+}
+
+// MapTo maps val to the interface type pointed to by ifacePtr, see inject.TypeMapper.
+func (inj *requestInjector) MapTo(val, ifacePtr any) inject.TypeMapper {
+	return inj.Set(inject.InterfaceOf(ifacePtr), reflect.ValueOf(val))
+}
+
+// Set maps val to t, see inject.TypeMapper.
+func (inj *requestInjector) Set(t reflect.Type, val reflect.Value) inject.TypeMapper {
+	inj.Injector.Set(t, val)
+	for i := range inj.mapped {
+		if inj.mapped[i].t == t {
+			inj.mapped[i].val = val
+			return inj
+		}
+	}
+	inj.mapped = append(inj.mapped, mappedValue{t, val})
+	return inj
+}
+
+// Value returns the value mapped to t, running its provider if needed.
+// A failing provider resolves to the zero reflect.Value.
+func (inj *requestInjector) Value(t reflect.Type) reflect.Value {
+	val, _ := inj.resolve(t)
+	return val
+}
+
+func (inj *requestInjector) resolve(t reflect.Type) (reflect.Value, error) {
+	if val := inj.Injector.Value(t); val.IsValid() {
+		return val, nil
+	}
+	if inj.c.engine == nil {
+		return reflect.Value{}, nil
+	}
+	p := inj.c.engine.providers[t]
+	if p == nil {
+		return reflect.Value{}, nil
+	}
+	if p.lifetime == Singleton {
+		return inj.c.engine.singleton(p, nil)
+	}
+
+	for _, resolving := range inj.resolving {
+		if resolving == t {
+			return reflect.Value{}, p.error(ErrProviderCycle)
+		}
+	}
+	inj.resolving = append(inj.resolving, t)
+	val, err := p.construct(inj.arg)
+	inj.resolving = inj.resolving[:len(inj.resolving)-1]
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	if p.lifetime == Scoped {
+		inj.Set(t, val)
+		inj.scoped = append(inj.scoped, val)
+	}
+	return val, nil
+}
+
+// Invoke calls f with its arguments resolved by the injector, see inject.Invoker.
+func (inj *requestInjector) Invoke(f any) ([]reflect.Value, error) {
+	return invokeWith(f, inj.arg)
+}
+
+func (inj *requestInjector) arg(t reflect.Type) (reflect.Value, error) {
+	val, err := inj.resolve(t)
+	if err != nil {
+		return val, err
+	}
+	if !val.IsValid() {
+		return val, fmt.Errorf("%w: %v", inject.ErrValueNotFound, t)
+	}
+	return val, nil
+}
+
+// release drops a holder of inj, disposing of it and releasing its parent after the
+// last one.
+func (inj *requestInjector) release() {
+	if inj.holders.Add(-1) == 0 {
+		inj.dispose()
+		if inj.parent != nil {
+			inj.parent.release()
+		}
+	}
+}
+
+// dispose closes the values of the scoped providers in the reverse order of their
+// construction, once the request is done.
+func (inj *requestInjector) dispose() {
+	for i := len(inj.scoped) - 1; i >= 0; i-- {
+		if closer, ok := inj.scoped[i].Interface().(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				debugPrint("[WARNING] cannot close %v at the end of the request: %v", inj.scoped[i].Type(), err)
+			}
+		}
+	}
+	inj.scoped = inj.scoped[:0]
+}
+
+// invokeWith calls f like inject.Invoker, with its arguments resolved by resolveArg.
+func invokeWith(f any, resolveArg func(reflect.Type) (reflect.Value, error)) ([]reflect.Value, error) {
+	t := reflect.TypeOf(f)
+	numIn := t.NumIn()
+	if invoker, ok := f.(inject.FastInvoker); ok {
+		var in []any
+		if numIn > 0 {
+			in = make([]any, numIn)
+			for i := 0; i < numIn; i++ {
+				val, err := resolveArg(t.In(i))
+				if err != nil {
+					return nil, err
+				}
+				in[i] = val.Interface()
+			}
+		}
+		return invoker.Invoke(in)
+	}
+
+	var in []reflect.Value
+	if numIn > 0 {
+		in = make([]reflect.Value, numIn)
+		for i := 0; i < numIn; i++ {
+			val, err := resolveArg(t.In(i))
+			if err != nil {
+				return nil, err
+			}
+			in[i] = val
+		}
+	}
+	return reflect.ValueOf(f).Call(in), nil
+}
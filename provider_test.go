@@ -0,0 +1,346 @@
+package jin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDB struct {
+	name string
+}
+
+type testRepo struct {
+	db   *testDB
+	path string
+}
+
+type testService struct {
+	repo *testRepo
+}
+
+func TestEngineProvide(t *testing.T) {
+	engine := New()
+	engine.Map(&testDB{name: "main"})
+
+	calls := 0
+	engine.Provide(func(c *Context, db *testDB) (*testRepo, error) {
+		calls++
+		return &testRepo{db: db, path: c.FullPath()}, nil
+	}, func(repo *testRepo) *testService {
+		return &testService{repo: repo}
+	})
+
+	engine.GET("/lazy", func(c *Context) {
+		c.String(http.StatusOK, "no repo needed")
+	})
+	engine.GET("/repo", func(repo *testRepo) {}, func(c *Context, repo *testRepo, svc *testService) {
+		assert.Same(t, repo, svc.repo)
+		c.String(http.StatusOK, repo.db.name+" "+repo.path)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/lazy", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "no repo needed", w.Body.String())
+	assert.Zero(t, calls)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/repo", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "main /repo", w.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// cached for one request only
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, 2, calls)
+}
+
+func TestEngineProvideError(t *testing.T) {
+	engine := New()
+	errConnect := errors.New("connection refused")
+	engine.Provide(func() (*testDB, error) {
+		return nil, errConnect
+	}, func(db *testDB) *testRepo {
+		return &testRepo{db: db}
+	})
+
+	var errs errorMsgs
+	engine.Use(func(c *Context) {
+		c.Next()
+		errs = c.Errors
+	})
+	engine.GET("/", func(c *Context, repo *testRepo) {
+		t.Error("the chain should have been aborted")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	assert.NotPanics(t, func() { engine.ServeHTTP(w, req) })
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errConnect)
+
+	var providerErr *ProviderError
+	assert.True(t, errors.As(errs[0], &providerErr))
+	assert.Equal(t, "*jin.testDB", providerErr.Type.String())
+	assert.Contains(t, errs[0].Error(), "unable to provide *jin.testDB with github.com/juanjiTech/jin.TestEngineProvideError.func1")
+}
+
+func TestEngineProvideCycle(t *testing.T) {
+	engine := New()
+	engine.Provide(func(repo *testRepo) *testDB {
+		return repo.db
+	}, func(db *testDB) *testRepo {
+		return &testRepo{db: db}
+	})
+	engine.GET("/", func(repo *testRepo) {})
+
+	var errs errorMsgs
+	engine.ErrorHandler = func(c *Context, err error) {
+		errs = c.Errors
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrProviderCycle)
+}
+
+func TestEngineProvideMissingDependency(t *testing.T) {
+	engine := New()
+	engine.Provide(func(db *testDB) *testRepo {
+		return &testRepo{db: db}
+	})
+	engine.GET("/", func(repo *testRepo) {})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	var errs errorMsgs
+	engine.ErrorHandler = func(c *Context, err error) { errs = c.Errors }
+	engine.ServeHTTP(w, req)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "value not found: *jin.testDB")
+}
+
+func TestEngineProvideInvalid(t *testing.T) {
+	engine := New()
+	assert.Panics(t, func() { engine.Provide("not a function") })
+	assert.Panics(t, func() { engine.Provide(func() {}) })
+	assert.Panics(t, func() { engine.Provide(func() (*testDB, string) { return nil, "" }) })
+	assert.Panics(t, func() { engine.Provide(func() error { return nil }) })
+	assert.Panics(t, func() { engine.Provide(func() (int, int, error) { return 0, 0, nil }) })
+
+	engine.Provide(func() *testDB { return nil })
+	assert.Panics(t, func() { engine.Provide(func() (*testDB, error) { return nil, nil }) })
+}
+
+type testTx struct {
+	closed *[]string
+	name   string
+}
+
+func (tx *testTx) Close() error {
+	*tx.closed = append(*tx.closed, tx.name)
+	return nil
+}
+
+type testTxName struct {
+	tx *testTx
+}
+
+func (n testTxName) String() string {
+	return n.tx.name
+}
+
+type testLogger struct {
+	tx *testTx
+}
+
+func TestEngineProvideWithLifetimes(t *testing.T) {
+	engine := New()
+	engine.Map(&testDB{name: "main"})
+
+	singletons, transients := 0, 0
+	engine.ProvideWith(Singleton, func(db *testDB) *testRepo {
+		singletons++
+		return &testRepo{db: db}
+	})
+	engine.ProvideWith(Transient, func(repo *testRepo) *testService {
+		transients++
+		return &testService{repo: repo}
+	})
+
+	engine.GET("/", func(s1 *testService, repo *testRepo) {
+		assert.Same(t, repo, s1.repo)
+	}, func(c *Context, s2 *testService) {
+		c.String(http.StatusOK, s2.repo.db.name)
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, "main", w.Body.String())
+	}
+	assert.Equal(t, 1, singletons)
+	assert.Equal(t, 4, transients)
+}
+
+func TestEngineProvideScopedDispose(t *testing.T) {
+	var closed []string
+	engine := New()
+	engine.Provide(func() *testTx {
+		return &testTx{closed: &closed, name: "tx"}
+	}, func(tx *testTx) *testLogger {
+		return &testLogger{tx: &testTx{closed: &closed, name: "logger"}}
+	})
+
+	var copied *Context
+	engine.GET("/", func(c *Context, logger *testLogger) {
+		assert.Empty(t, closed)
+		c.Status(http.StatusNoContent)
+	})
+	engine.GET("/copy", func(c *Context, tx *testTx) {
+		copied = c.Copy()
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, []string{"tx"}, closed)
+
+	// values still reachable through a copy are left open
+	closed = nil
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/copy", nil)
+	engine.ServeHTTP(w, req)
+	assert.Empty(t, closed)
+	assert.NotNil(t, copied.Value(reflect.TypeOf(&testTx{})))
+	copied.Release()
+	assert.Equal(t, []string{"tx"}, closed)
+}
+
+func TestContextCopyScope(t *testing.T) {
+	var closed []string
+	var built atomic.Int32
+	engine := New()
+	engine.Provide(func() *testTx {
+		return &testTx{closed: &closed, name: fmt.Sprint("tx", built.Add(1))}
+	})
+
+	txType := reflect.TypeOf(&testTx{})
+	var copied *Context
+	engine.GET("/", func(c *Context) {
+		copied = c.Copy()
+		// the copy builds its values in its own scope
+		assert.Equal(t, "tx1", copied.Value(txType).(*testTx).name)
+		assert.Equal(t, "tx2", c.Value(txType).(*testTx).name)
+		assert.Equal(t, "tx1", copied.Value(txType).(*testTx).name)
+		c.Release()
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Empty(t, closed)
+
+	copied.Release()
+	assert.Equal(t, []string{"tx1", "tx2"}, closed)
+	copied.Release()
+	assert.Equal(t, []string{"tx1", "tx2"}, closed)
+
+	// a copy used by a goroutine while the request goes on, which builds its own
+	// value as the request hadn't built one when it was copied
+	closed = nil
+	built.Store(0)
+	done := make(chan struct{})
+	engine.GET("/async", func(c *Context) {
+		cp := c.Copy()
+		go func() {
+			defer close(done)
+			defer cp.Release()
+			cp.Value(txType)
+		}()
+		c.Value(txType)
+	})
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/async", nil)
+	engine.ServeHTTP(w, req)
+	<-done
+	assert.Equal(t, int32(2), built.Load())
+	assert.Len(t, closed, 2)
+}
+
+func TestContextCopyScopeConcurrent(t *testing.T) {
+	var closed []string
+	var built atomic.Int32
+	engine := New()
+	engine.Provide(func() *testTx {
+		return &testTx{closed: &closed, name: fmt.Sprint("tx", built.Add(1))}
+	})
+	// looked up among the implementations of the values of the injectors first
+	engine.ProvideWith(Transient, func(tx *testTx) fmt.Stringer {
+		return testTxName{tx}
+	})
+
+	stringerType := reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	started, done := make(chan struct{}), make(chan struct{})
+	engine.GET("/", func(c *Context) {
+		assert.Equal(t, "tx1", c.Value(stringerType).(fmt.Stringer).String())
+		cp := c.Copy()
+		go func() {
+			defer close(done)
+			defer cp.Release()
+			close(started)
+			for i := 0; i < 1000; i++ {
+				assert.Equal(t, "tx1", cp.Value(stringerType).(fmt.Stringer).String())
+			}
+		}()
+		<-started
+		for i := 0; i < 1000; i++ {
+			c.Map(i)
+			c.Value(stringerType)
+		}
+		<-done
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, []string{"tx1"}, closed)
+}
+
+func TestEngineProvideSingletonScopedDependency(t *testing.T) {
+	engine := New()
+	assert.PanicsWithValue(t, "singleton provider github.com/juanjiTech/jin.TestEngineProvideSingletonScopedDependency.func1.1 depends on request-scoped *jin.Context", func() {
+		engine.ProvideWith(Singleton, func(c *Context) *testRepo { return nil })
+	})
+
+	// the check is run again when a dependency is registered later
+	engine = New()
+	engine.ProvideWith(Singleton, func(svc *testService) *testRepo { return nil })
+	engine.ProvideWith(Transient, func(db *testDB) *testService { return nil })
+	assert.Panics(t, func() {
+		engine.Provide(func() *testDB { return nil })
+	})
+
+	engine = New()
+	engine.Map(&testDB{})
+	engine.ProvideWith(Singleton, func(db *testDB) *testRepo { return nil })
+	assert.NotPanics(t, func() {
+		engine.Provide(func() *testDB { return nil })
+	})
+
+	assert.Panics(t, func() {
+		engine.ProvideWith(Lifetime(42), func() *testService { return nil })
+	})
+}
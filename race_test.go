@@ -0,0 +1,7 @@
+//go:build race
+
+package jin
+
+// raceEnabled reports whether the tests run with the race detector, which makes
+// the code under test allocate.
+const raceEnabled = true
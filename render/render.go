@@ -12,6 +12,19 @@ type Render interface {
 	WriteContentType(w http.ResponseWriter)
 }
 
+var (
+	_ Render = (*JSON)(nil)
+	_ Render = (*IndentedJSON)(nil)
+	_ Render = (*SecureJSON)(nil)
+	_ Render = (*JsonpJSON)(nil)
+	_ Render = (*AsciiJSON)(nil)
+	_ Render = (*PureJSON)(nil)
+	_ Render = (*YAML)(nil)
+	_ Render = (*String)(nil)
+	_ Render = (*Data)(nil)
+	_ Render = (*Redirect)(nil)
+)
+
 func writeContentType(w http.ResponseWriter, value []string) {
 	header := w.Header()
 	if val := header["Content-Type"]; len(val) == 0 {
@@ -2,6 +2,7 @@ package render
 
 import (
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -167,3 +168,45 @@ func TestRenderWithError(t *testing.T) {
 	err = YAML{Data: unmarshalable{F: func() {}}}.Render(wYaml)
 	assert.Error(t, err)
 }
+
+func TestString(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := String{Format: "hello %s %d", Data: []any{"jin", 1}}.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello jin 1", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+
+	// without data the format is written verbatim
+	w = httptest.NewRecorder()
+	err = String{Format: "100%"}.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "100%", w.Body.String())
+}
+
+func TestData(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := Data{ContentType: "image/png", Data: []byte("#!PNG some raw data")}.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, "#!PNG some raw data", w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+}
+
+func TestRedirect(t *testing.T) {
+	req, err := http.NewRequest("GET", "/test-redirect", nil)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	err = Redirect{Code: http.StatusMovedPermanently, Request: req, Location: "/new/location"}.Render(w)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/new/location", w.Header().Get("Location"))
+
+	// a 201 is allowed to carry a Location
+	w = httptest.NewRecorder()
+	err = Redirect{Code: http.StatusCreated, Request: req, Location: "/new/location"}.Render(w)
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() {
+		_ = Redirect{Code: http.StatusOK, Request: req, Location: "/new/location"}.Render(httptest.NewRecorder())
+	})
+}
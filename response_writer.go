@@ -6,6 +6,7 @@ package jin
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"net"
 	"net/http"
@@ -122,3 +123,74 @@ func (w *responseWriter) Pusher() (pusher http.Pusher) {
 	}
 	return nil
 }
+
+// ErrDetachedWriter is returned when writing to the Writer of a copied Context.
+var ErrDetachedWriter = errors.New("jin: the response writer of a copied context is read-only")
+
+// detachedResponseWriter is the ResponseWriter of a copied Context. It reports the
+// state of the original response at copy time and rejects every write.
+type detachedResponseWriter struct {
+	header  http.Header
+	status  int
+	size    int
+	written bool
+}
+
+var _ ResponseWriter = (*detachedResponseWriter)(nil)
+
+func newDetachedResponseWriter(w ResponseWriter) *detachedResponseWriter {
+	dw := &detachedResponseWriter{status: defaultStatus, size: noWritten}
+	if w != nil {
+		dw.header = w.Header().Clone()
+		dw.status = w.Status()
+		dw.size = w.Size()
+		dw.written = w.Written()
+	}
+	if dw.header == nil {
+		dw.header = make(http.Header)
+	}
+	return dw
+}
+
+// Header returns a snapshot of the response headers, changes to it are not sent.
+func (w *detachedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *detachedResponseWriter) WriteHeader(int) {
+	debugPrint("[WARNING] Trying to write headers through a copied context.")
+}
+
+func (w *detachedResponseWriter) WriteHeaderNow() {}
+
+func (w *detachedResponseWriter) Write([]byte) (int, error) {
+	return 0, ErrDetachedWriter
+}
+
+func (w *detachedResponseWriter) WriteString(string) (int, error) {
+	return 0, ErrDetachedWriter
+}
+
+func (w *detachedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *detachedResponseWriter) Size() int {
+	return w.size
+}
+
+func (w *detachedResponseWriter) Written() bool {
+	return w.written
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *detachedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, ErrDetachedWriter
+}
+
+// Flush implements the http.Flusher interface.
+func (w *detachedResponseWriter) Flush() {}
+
+func (w *detachedResponseWriter) Pusher() http.Pusher {
+	return nil
+}
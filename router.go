@@ -1,8 +1,12 @@
 package jin
 
 import (
+	"maps"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
 )
 
 var (
@@ -26,16 +30,83 @@ type IRouter interface {
 type IRoutes interface {
 	Use(middleware ...HandlerFunc) IRoutes
 
-	Handle(method string, relativePath string, handlers ...HandlerFunc) IRoutes
-	Any(relativePath string, handlers ...HandlerFunc) IRoutes
-	GET(relativePath string, handlers ...HandlerFunc) IRoutes
-	POST(relativePath string, handlers ...HandlerFunc) IRoutes
-	DELETE(relativePath string, handlers ...HandlerFunc) IRoutes
-	PATCH(relativePath string, handlers ...HandlerFunc) IRoutes
-	PUT(relativePath string, handlers ...HandlerFunc) IRoutes
-	OPTIONS(relativePath string, handlers ...HandlerFunc) IRoutes
-	HEAD(relativePath string, handlers ...HandlerFunc) IRoutes
-	Match(methods []string, relativePath string, handlers ...HandlerFunc) IRoutes
+	Handle(method string, relativePath string, handlers ...HandlerFunc) *Route
+	Any(relativePath string, handlers ...HandlerFunc) *Route
+	GET(relativePath string, handlers ...HandlerFunc) *Route
+	POST(relativePath string, handlers ...HandlerFunc) *Route
+	DELETE(relativePath string, handlers ...HandlerFunc) *Route
+	PATCH(relativePath string, handlers ...HandlerFunc) *Route
+	PUT(relativePath string, handlers ...HandlerFunc) *Route
+	OPTIONS(relativePath string, handlers ...HandlerFunc) *Route
+	HEAD(relativePath string, handlers ...HandlerFunc) *Route
+	Match(methods []string, relativePath string, handlers ...HandlerFunc) *Route
+}
+
+// Route is returned by the registration of a route, to configure it. It also
+// implements IRoutes, so that the registrations can be chained as usual:
+//
+//	router.GET("/users/:id", getUser).Name("user").
+//	    DELETE("/users/:id", deleteUser)
+type Route struct {
+	IRoutes
+	// entries has one element per method the route was registered for.
+	entries []*routeEntry
+}
+
+// Name names the route, for Engine.URL to build its path. It panics if the name is
+// already used by another path.
+func (r *Route) Name(name string) *Route {
+	if name == "" {
+		panic("route name can not be empty")
+	}
+	r.update(func(entry *routeEntry) {
+		entry.group.engine.nameRoute(name, entry.info.Path)
+		entry.info.Name = name
+	})
+	return r
+}
+
+// Meta attaches a value to the route, which middleware can read at request time
+// with Context.Route.
+//
+//	router.DELETE("/users/:id", deleteUser).Meta("permission", "users:delete")
+//
+//	func authorize(c *jin.Context) {
+//	    permission, _ := c.Route().Meta["permission"].(string)
+//	    ...
+//	}
+func (r *Route) Meta(key string, value any) *Route {
+	r.update(func(entry *routeEntry) {
+		meta := make(map[string]any, len(entry.info.Meta)+1)
+		maps.Copy(meta, entry.info.Meta)
+		meta[key] = value
+		entry.info.Meta = meta
+	})
+	return r
+}
+
+// Tags adds tags to the route, see Meta.
+func (r *Route) Tags(tags ...string) *Route {
+	r.update(func(entry *routeEntry) {
+		entry.info.Tags = append(slices.Clip(entry.info.Tags), tags...)
+	})
+	return r
+}
+
+// update replaces the entries of r with copies changed by fn, in a new route table.
+// The entries of a published table are read by the requests being served, so they
+// are never modified. The entries removed with RouterGroup.RemoveRoute are left as is.
+func (r *Route) update(fn func(entry *routeEntry)) {
+	for i, entry := range r.entries {
+		entry.group.engine.updateRoutes(func(table *routeTable) {
+			updated := *entry
+			if table.replaceEntry(entry, &updated) {
+				// not published until updateRoutes returns
+				fn(&updated)
+				r.entries[i] = &updated
+			}
+		})
+	}
 }
 
 // RouterGroup is used internally to configure router, a RouterGroup is associated with
@@ -45,6 +116,24 @@ type RouterGroup struct {
 	basePath string
 	engine   *Engine
 	root     bool
+	parent   *RouterGroup
+	errors   *ErrorRegistry
+	// host is set for the groups of Engine.Host.
+	host *hostPattern
+	// version and versionSelectors are set for the groups of RouterGroup.Version.
+	version          string
+	versionSelectors []VersionSelector
+}
+
+// routeEntry is stored in the tree next to the handlers of a route.
+type routeEntry struct {
+	group *RouterGroup
+	// info is returned by Engine.Routes and Context.Route.
+	info RouteInfo
+	// plans are the invocation plans of the handlers, computed by Engine.addRoute.
+	plans []handlerPlan
+	// seq is the rank of the route in the registrations.
+	seq uint64
 }
 
 var _ IRouter = (*RouterGroup)(nil)
@@ -62,20 +151,40 @@ func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *R
 		Handlers: group.combineHandlers(handlers),
 		basePath: group.calculateAbsolutePath(relativePath),
 		engine:   group.engine,
+		parent:   group,
+		host:     group.host,
+
+		version:          group.version,
+		versionSelectors: group.versionSelectors,
 	}
 }
 
+// Errors returns the ErrorRegistry of the group. The DefaultErrorHandler maps the
+// errors of a route with the registry of its group first, then with the ones of the
+// enclosing groups up to the engine, so a group can override the engine wide mappings.
+func (group *RouterGroup) Errors() *ErrorRegistry {
+	if group.errors == nil {
+		group.errors = &ErrorRegistry{}
+	}
+	return group.errors
+}
+
 // BasePath returns the base path of router group.
 // For example, if v := router.Group("/rest/n/v1/api"), v.BasePath() is "/rest/n/v1/api".
 func (group *RouterGroup) BasePath() string {
 	return group.basePath
 }
 
-func (group *RouterGroup) handle(httpMethod, relativePath string, handlers HandlersChain) IRoutes {
+func (group *RouterGroup) handle(httpMethod, relativePath string, handlers HandlersChain) *routeEntry {
 	absolutePath := group.calculateAbsolutePath(relativePath)
 	handlers = group.combineHandlers(handlers)
-	group.engine.addRoute(httpMethod, absolutePath, handlers)
-	return group.returnObj()
+	route := &routeEntry{group: group}
+	group.engine.addRoute(httpMethod, absolutePath, handlers, route)
+	return route
+}
+
+func (group *RouterGroup) returnRoute(entries ...*routeEntry) *Route {
+	return &Route{IRoutes: group.returnObj(), entries: entries}
 }
 
 // Handle registers a new request handle and middleware with the given path and method.
@@ -88,65 +197,127 @@ func (group *RouterGroup) handle(httpMethod, relativePath string, handlers Handl
 // This function is intended for bulk loading and to allow the usage of less
 // frequently used, non-standardized or custom methods (e.g. for internal
 // communication with a proxy).
-func (group *RouterGroup) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) IRoutes {
+func (group *RouterGroup) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) *Route {
 	if matched := regEnLetter.MatchString(httpMethod); !matched {
 		panic("http method " + httpMethod + " is not valid")
 	}
-	return group.handle(httpMethod, relativePath, handlers)
+	return group.returnRoute(group.handle(httpMethod, relativePath, handlers))
 }
 
 // POST is a shortcut for router.Handle("POST", path, handlers).
-func (group *RouterGroup) POST(relativePath string, handlers ...HandlerFunc) IRoutes {
-	return group.handle(http.MethodPost, relativePath, handlers)
+func (group *RouterGroup) POST(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.returnRoute(group.handle(http.MethodPost, relativePath, handlers))
 }
 
 // GET is a shortcut for router.Handle("GET", path, handlers).
-func (group *RouterGroup) GET(relativePath string, handlers ...HandlerFunc) IRoutes {
-	return group.handle(http.MethodGet, relativePath, handlers)
+func (group *RouterGroup) GET(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.returnRoute(group.handle(http.MethodGet, relativePath, handlers))
 }
 
 // DELETE is a shortcut for router.Handle("DELETE", path, handlers).
-func (group *RouterGroup) DELETE(relativePath string, handlers ...HandlerFunc) IRoutes {
-	return group.handle(http.MethodDelete, relativePath, handlers)
+func (group *RouterGroup) DELETE(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.returnRoute(group.handle(http.MethodDelete, relativePath, handlers))
 }
 
 // PATCH is a shortcut for router.Handle("PATCH", path, handlers).
-func (group *RouterGroup) PATCH(relativePath string, handlers ...HandlerFunc) IRoutes {
-	return group.handle(http.MethodPatch, relativePath, handlers)
+func (group *RouterGroup) PATCH(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.returnRoute(group.handle(http.MethodPatch, relativePath, handlers))
 }
 
 // PUT is a shortcut for router.Handle("PUT", path, handlers).
-func (group *RouterGroup) PUT(relativePath string, handlers ...HandlerFunc) IRoutes {
-	return group.handle(http.MethodPut, relativePath, handlers)
+func (group *RouterGroup) PUT(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.returnRoute(group.handle(http.MethodPut, relativePath, handlers))
 }
 
 // OPTIONS is a shortcut for router.Handle("OPTIONS", path, handlers).
-func (group *RouterGroup) OPTIONS(relativePath string, handlers ...HandlerFunc) IRoutes {
-	return group.handle(http.MethodOptions, relativePath, handlers)
+func (group *RouterGroup) OPTIONS(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.returnRoute(group.handle(http.MethodOptions, relativePath, handlers))
 }
 
 // HEAD is a shortcut for router.Handle("HEAD", path, handlers).
-func (group *RouterGroup) HEAD(relativePath string, handlers ...HandlerFunc) IRoutes {
-	return group.handle(http.MethodHead, relativePath, handlers)
+func (group *RouterGroup) HEAD(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.returnRoute(group.handle(http.MethodHead, relativePath, handlers))
 }
 
 // Any registers a route that matches all the HTTP methods.
 // GET, POST, PUT, PATCH, HEAD, OPTIONS, DELETE, CONNECT, TRACE.
-func (group *RouterGroup) Any(relativePath string, handlers ...HandlerFunc) IRoutes {
-	for _, method := range anyMethods {
-		group.handle(method, relativePath, handlers)
-	}
-
-	return group.returnObj()
+func (group *RouterGroup) Any(relativePath string, handlers ...HandlerFunc) *Route {
+	return group.Match(anyMethods, relativePath, handlers...)
 }
 
 // Match registers a route that matches the specified methods that you declared.
-func (group *RouterGroup) Match(methods []string, relativePath string, handlers ...HandlerFunc) IRoutes {
-	for _, method := range methods {
-		group.handle(method, relativePath, handlers)
+func (group *RouterGroup) Match(methods []string, relativePath string, handlers ...HandlerFunc) *Route {
+	entries := make([]*routeEntry, len(methods))
+	for i, method := range methods {
+		entries[i] = group.handle(method, relativePath, handlers)
 	}
+	return group.returnRoute(entries...)
+}
 
-	return group.returnObj()
+// Mount registers handler for every method at prefix and under it, with the prefix
+// stripped from the URL.Path and URL.RawPath of the requests it serves, so that a
+// handler built for the root of a server, like an admin UI, a metrics handler or
+// another Engine, can be served under a prefix:
+//
+//	router.Mount("/debug/pprof", pprofMux)
+//	router.Mount("/billing", billing.NewEngine())
+//
+// The middleware of the group run before handler. A mounted Engine keeps its own
+// injector, with the injector of this engine set as its parent, so that the values
+// mapped here can be injected in its handlers.
+func (group *RouterGroup) Mount(prefix string, handler http.Handler) *Route {
+	if handler == nil {
+		panic("handler can not be nil")
+	}
+	if sub, ok := handler.(*Engine); ok {
+		if sub == group.engine {
+			panic("an engine can not be mounted on itself")
+		}
+		sub.Injector.SetParent(group.engine.Injector)
+	}
+
+	absolutePath := group.calculateAbsolutePath(prefix)
+	// the segments of the prefix are stripped rather than the prefix itself, as it
+	// may have parameters or be escaped differently in the RawPath
+	segments := strings.Count(strings.TrimSuffix(absolutePath, "/"), "/")
+	mounted := func(c *Context) {
+		req := new(http.Request)
+		*req = *c.Request
+		u := new(url.URL)
+		*u = *c.Request.URL
+		u.Path = stripSegments(u.Path, segments)
+		if u.RawPath != "" {
+			u.RawPath = stripSegments(u.RawPath, segments)
+		}
+		req.URL = u
+		handler.ServeHTTP(c.Writer, req)
+	}
+
+	var entries []*routeEntry
+	if absolutePath != "/" {
+		for _, method := range anyMethods {
+			entries = append(entries, group.handle(method, strings.TrimSuffix(prefix, "/"), HandlersChain{mounted}))
+		}
+	}
+	for _, method := range anyMethods {
+		entries = append(entries, group.handle(method, joinPaths(prefix, "/*"+mountParam), HandlersChain{mounted}))
+	}
+	return group.returnRoute(entries...)
+}
+
+// mountParam is the name of the catch-all parameter of the routes of Mount.
+const mountParam = "path"
+
+// stripSegments removes the n first segments of the path p.
+func stripSegments(p string, n int) string {
+	for ; n > 0; n-- {
+		i := strings.IndexByte(p[min(1, len(p)):], '/')
+		if i < 0 {
+			return "/"
+		}
+		p = p[i+1:]
+	}
+	return p
 }
 
 func (group *RouterGroup) combineHandlers(handlers HandlersChain) HandlersChain {
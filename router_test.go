@@ -3,6 +3,7 @@ package jin
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -242,3 +243,188 @@ func TestRouterHandleAndMatch(t *testing.T) {
 		assert.Equal(t, "POST", wPost.Body.String())
 	})
 }
+
+func TestRouteChaining(t *testing.T) {
+	engine := New()
+	group := engine.Group("/api")
+	route := group.GET("/a", handlerTest1)
+	assert.Same(t, group, route.IRoutes)
+	route.POST("/b", handlerTest1).Any("/c", handlerTest2)
+	assert.Same(t, engine, engine.GET("/", handlerTest1).IRoutes)
+
+	_, _, ok := engine.MatchRoute(http.MethodPost, "/api/b")
+	assert.True(t, ok)
+	_, _, ok = engine.MatchRoute(http.MethodTrace, "/api/c")
+	assert.True(t, ok)
+}
+
+func TestRouteNameAndURL(t *testing.T) {
+	engine := New()
+	users := engine.Group("/users")
+	users.GET("/:id", handlerTest1).Name("user")
+	users.Match([]string{http.MethodGet, http.MethodPut}, "/:id/files/*path", handlerTest1).Name("file")
+	engine.GET("/about", handlerTest2).Name("about")
+
+	url, err := engine.URL("about")
+	assert.NoError(t, err)
+	assert.Equal(t, "/about", url)
+
+	url, err = engine.URL("user", "id", "a b/c")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/a%20b%2Fc", url)
+
+	url, err = engine.URL("file", "path", "/docs/a b.txt", "id", "42")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/42/files/docs/a%20b.txt", url)
+	url, err = engine.URL("file", "path", "", "id", "42")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/42/files/", url)
+
+	_, err = engine.URL("missing")
+	assert.ErrorIs(t, err, ErrRouteNotFound)
+	_, err = engine.URL("user")
+	assert.EqualError(t, err, `route "user": missing parameter "id"`)
+	_, err = engine.URL("user", "id")
+	assert.Error(t, err)
+	_, err = engine.URL("user", "id", "1", "name", "x")
+	assert.EqualError(t, err, `route "user": unknown parameter "name"`)
+
+	assert.Panics(t, func() {
+		engine.GET("/other", handlerTest1).Name("user")
+	})
+	assert.Panics(t, func() {
+		engine.GET("/empty", handlerTest1).Name("")
+	})
+}
+
+func TestRouteConstrainedParams(t *testing.T) {
+	engine := New()
+	engine.GET("/users/:id<int>", func(c *Context) {
+		id, err := c.Params.Int("id")
+		assert.NoError(t, err)
+		c.String(http.StatusOK, "id %d", id)
+	}).Name("user")
+	engine.GET("/users/:name", func(c *Context) {
+		c.String(http.StatusOK, "name %s", c.Param("name"))
+	})
+	engine.GET("/orders/:id<uuid>", func(c *Context) {
+		c.String(http.StatusOK, "order %s", c.Param("id"))
+	}).Name("order")
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	assert.Equal(t, "id 42", w.Body.String())
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/john", nil))
+	assert.Equal(t, "name john", w.Body.String())
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/123e4567-e89b-12d3-a456-426614174000", nil))
+	assert.Equal(t, "order 123e4567-e89b-12d3-a456-426614174000", w.Body.String())
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	route, params, ok := engine.MatchRoute(http.MethodGet, "/users/7")
+	assert.True(t, ok)
+	assert.Equal(t, "/users/:id<int>", route.Path)
+	assert.Equal(t, Params{{Key: "id", Value: "7"}}, params)
+
+	url, err := engine.URL("user", "id", "42")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/42", url)
+	_, err = engine.URL("user", "id", "john")
+	assert.EqualError(t, err, `route "user": parameter "id" doesn't match <int>: "john"`)
+	_, err = engine.URL("order", "id", "42")
+	assert.Error(t, err)
+}
+
+func TestRouteMetaAndTags(t *testing.T) {
+	engine := New()
+	var permission any
+	engine.Use(func(c *Context) {
+		route := c.Route()
+		permission = route.Meta["permission"]
+		c.Writer.Header().Set("X-Tags", strings.Join(route.Tags, ","))
+		c.Writer.Header().Set("X-Route", route.Name)
+	})
+	engine.DELETE("/users/:id", handlerTest1).
+		Name("deleteUser").
+		Meta("permission", "users:delete").
+		Meta("deprecated", "2025-01-01").
+		Tags("users").Tags("admin")
+	engine.GET("/", handlerTest1)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/users/1", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "users:delete", permission)
+	assert.Equal(t, "users,admin", w.Header().Get("X-Tags"))
+	assert.Equal(t, "deleteUser", w.Header().Get("X-Route"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	engine.ServeHTTP(w, req)
+	assert.Nil(t, permission)
+	assert.Empty(t, w.Header().Get("X-Tags"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/missing", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	route, _, _ := engine.MatchRoute(http.MethodDelete, "/users/1")
+	assert.Equal(t, map[string]any{"permission": "users:delete", "deprecated": "2025-01-01"}, route.Meta)
+	assert.Equal(t, []string{"users", "admin"}, route.Tags)
+	assert.Equal(t, "deleteUser", route.Name)
+}
+
+func TestRouterGroupMount(t *testing.T) {
+	type greeting string
+
+	engine := New()
+	engine.Map(greeting("hello"))
+	var seen []string
+	admin := engine.Group("/admin", func(c *Context) {
+		seen = append(seen, c.Request.URL.Path)
+	})
+	admin.Mount("/ui", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.URL.RawPath))
+	}))
+
+	billing := New()
+	billing.GET("/invoices/:id", func(c *Context, g greeting) {
+		c.String(http.StatusOK, "%s invoice %s", g, c.Param("id"))
+	})
+	engine.Mount("/orgs/:org/billing/", billing)
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{http.MethodGet, "/admin/ui", http.StatusOK, "GET / "},
+		{http.MethodPost, "/admin/ui/", http.StatusOK, "POST / "},
+		{http.MethodDelete, "/admin/ui/users/a%2Fb", http.StatusOK, "DELETE /users/a/b /users/a%2Fb"},
+		{http.MethodGet, "/orgs/acme/billing/invoices/7", http.StatusOK, "hello invoice 7"},
+		{http.MethodGet, "/orgs/acme/billing/other", http.StatusNotFound, "404 page not found"},
+		{http.MethodGet, "/admin/uix", http.StatusNotFound, "404 page not found"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.code, w.Code, tt.path)
+		assert.Equal(t, tt.body, w.Body.String(), tt.path)
+	}
+	assert.Equal(t, []string{"/admin/ui", "/admin/ui/", "/admin/ui/users/a/b"}, seen)
+
+	root := New()
+	root.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	w := httptest.NewRecorder()
+	root.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/a/b", nil))
+	assert.Equal(t, "/a/b", w.Body.String())
+
+	assert.Panics(t, func() { root.Mount("/self", root) })
+	assert.Panics(t, func() { root.Mount("/nil", nil) })
+}
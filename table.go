@@ -0,0 +1,265 @@
+package jin
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// routeTable holds the routes of an Engine and what serving them needs. A published
+// table is never modified: the registrations change a copy which replaces it
+// atomically, so that routes can be added and removed while requests are served.
+// The copy only duplicates the nodes on the path of the change, the others are
+// shared between the tables.
+type routeTable struct {
+	trees methodTrees
+	// hosts are the routes of Engine.Host, the patterns with the fewer wildcards
+	// first.
+	hosts       []*hostTree
+	maxParams   uint16
+	maxSections uint16
+}
+
+var emptyRouteTable = &routeTable{}
+
+// table returns the current route table.
+func (engine *Engine) table() *routeTable {
+	if table := engine.routes.Load(); table != nil {
+		return table
+	}
+	return emptyRouteTable
+}
+
+// updateRoutes calls fn with a copy of the route table, and publishes it once fn
+// returns. Nothing is published if fn panics, like on a route conflict.
+func (engine *Engine) updateRoutes(fn func(table *routeTable)) {
+	engine.routesMu.Lock()
+	defer engine.routesMu.Unlock()
+
+	table := *engine.table()
+	table.trees = maps.Clone(table.trees)
+	table.hosts = slices.Clone(table.hosts)
+	fn(&table)
+	engine.routes.Store(&table)
+}
+
+// hostTrees returns the route trees of host in the table, adding them if they don't
+// exist yet, or the ones of any host if host is nil. They can be modified.
+func (table *routeTable) hostTrees(host *hostPattern) *methodTrees {
+	if host == nil {
+		return &table.trees
+	}
+	for i, tree := range table.hosts {
+		if tree.pattern == host.pattern {
+			tree := &hostTree{hostPattern: tree.hostPattern, trees: maps.Clone(tree.trees)}
+			table.hosts[i] = tree
+			return &tree.trees
+		}
+	}
+	tree := &hostTree{hostPattern: host}
+	table.hosts = append(table.hosts, tree)
+	// stable, so that the patterns with as many wildcards keep their order
+	slices.SortStableFunc(table.hosts, func(a, b *hostTree) int {
+		return int(a.params) - int(b.params)
+	})
+	return &tree.trees
+}
+
+// existingHostTrees is hostTrees, except that it returns nil instead of adding the
+// trees of host if there are none.
+func (table *routeTable) existingHostTrees(host *hostPattern) *methodTrees {
+	if host != nil && !slices.ContainsFunc(table.hosts, func(tree *hostTree) bool {
+		return tree.pattern == host.pattern
+	}) {
+		return nil
+	}
+	return table.hostTrees(host)
+}
+
+// root returns a copy of the root of the tree of method, which replaces the tree in
+// trees, or a new root if there is no such tree. The static routes of the new tree
+// are looked up again from its root.
+func (trees *methodTrees) root(method string) *node {
+	if *trees == nil {
+		*trees = make(methodTrees)
+	}
+	root := &node{fullPath: "/"}
+	if current := (*trees)[method]; current != nil {
+		root = current.root.clone()
+	}
+	(*trees)[method] = &methodTree{root: root}
+	return root
+}
+
+// RemoveRoute removes the route registered in the group for method and relativePath,
+// the path being the one the route was registered with, like "/users/:id", with all
+// its versions. The routes of a Host group are removed through a group of the same
+// host pattern:
+//
+//	router.Host("{tenant}.example.com").RemoveRoute(http.MethodGet, "/users/:id")
+//
+// It is safe to call it while serving requests, the requests being handled by the
+// route complete normally. It returns an error wrapping ErrRouteNotFound if there is
+// no such route.
+func (group *RouterGroup) RemoveRoute(method, relativePath string) error {
+	engine := group.engine
+	path := group.calculateAbsolutePath(relativePath)
+	found := false
+	engine.updateRoutes(func(table *routeTable) {
+		trees := table.existingHostTrees(group.host)
+		if trees == nil {
+			return
+		}
+		tree := (*trees)[method]
+		if tree == nil {
+			return
+		}
+
+		// the tree is rebuilt without the route, in the order of the registrations
+		// for the :params sharing a segment to keep theirs
+		var removed []*routeEntry
+		var routes []*routeEntry
+		tree.root.walk(func(n *node) {
+			switch {
+			case n.handlers == nil:
+			case n.route.info.Path == path:
+				removed = n.entries()
+			default:
+				routes = append(routes, n.entries()...)
+			}
+		})
+		if removed == nil {
+			return
+		}
+		found = true
+		slices.SortFunc(routes, func(a, b *routeEntry) int {
+			return cmp.Compare(a.seq, b.seq)
+		})
+
+		if len(routes) > 0 {
+			root := &node{fullPath: "/"}
+			for _, route := range routes {
+				root.insertEntry(route)
+			}
+			(*trees)[method] = &methodTree{root: root}
+		} else if delete(*trees, method); len(*trees) == 0 && group.host != nil {
+			table.hosts = slices.DeleteFunc(table.hosts, func(tree *hostTree) bool {
+				return tree.pattern == group.host.pattern
+			})
+		}
+		for _, route := range removed {
+			if name := route.info.Name; name != "" && !table.hasRouteNamed(name) {
+				delete(engine.routeNames, name)
+			}
+		}
+	})
+	if !found {
+		if group.host != nil {
+			return fmt.Errorf("%w: %s %s%s", ErrRouteNotFound, method, group.host.pattern, path)
+		}
+		return fmt.Errorf("%w: %s %s", ErrRouteNotFound, method, path)
+	}
+	return nil
+}
+
+// replaceEntry replaces old with route in the table, copying the nodes down to it.
+// It reports whether old was found.
+func (table *routeTable) replaceEntry(old, route *routeEntry) bool {
+	trees := table.existingHostTrees(old.group.host)
+	if trees == nil {
+		return false
+	}
+	tree := (*trees)[old.info.Method]
+	if tree == nil {
+		return false
+	}
+	root := tree.root.clone()
+	if !root.replaceEntry(old.info.Path, old, route) {
+		return false
+	}
+	(*trees)[old.info.Method] = &methodTree{root: root}
+	return true
+}
+
+// replaceEntry replaces old, registered for path below n, with route, cloning the
+// nodes down to it. n must be a clone. It reports whether old was found.
+func (n *node) replaceEntry(path string, old, route *routeEntry) bool {
+	path, ok := strings.CutPrefix(path, n.path)
+	if !ok {
+		return false
+	}
+	if path == "" && n.handlers != nil {
+		found := n.route == old
+		if found {
+			n.route = route
+		}
+		if n.versions != nil {
+			if i := slices.Index(n.versions.routes, old); i >= 0 {
+				set := *n.versions
+				set.routes = slices.Clone(set.routes)
+				set.routes[i] = route
+				n.versions = &set
+				found = true
+			}
+		}
+		return found
+	}
+	for i, child := range n.children {
+		if strings.HasPrefix(path, child.path) {
+			if n.cloneChild(i).replaceEntry(path, old, route) {
+				return true
+			}
+			n.children[i] = child
+		}
+	}
+	return false
+}
+
+func (table *routeTable) hasRouteNamed(name string) bool {
+	found := false
+	table.walk(func(route *routeEntry) {
+		found = found || route.info.Name == name
+	})
+	return found
+}
+
+// walk calls fn for the routes of the table, the ones of any host first, by method.
+func (table *routeTable) walk(fn func(*routeEntry)) {
+	walk := func(trees methodTrees) {
+		methods := make([]string, 0, len(trees))
+		for method := range trees {
+			methods = append(methods, method)
+		}
+		slices.Sort(methods)
+		for _, method := range methods {
+			trees[method].root.walk(func(n *node) {
+				if n.handlers != nil {
+					for _, route := range n.entries() {
+						fn(route)
+					}
+				}
+			})
+		}
+	}
+	walk(table.trees)
+	for _, host := range table.hosts {
+		walk(host.trees)
+	}
+}
+
+// clone returns a copy of n which can be modified without modifying n, its children
+// being shared until they are cloned in turn with cloneChild.
+func (n *node) clone() *node {
+	c := *n
+	c.children = slices.Clone(n.children)
+	return &c
+}
+
+// cloneChild replaces the i-th child of n with a clone, and returns it.
+func (n *node) cloneChild(i int) *node {
+	child := n.children[i].clone()
+	n.children[i] = child
+	return child
+}
@@ -0,0 +1,194 @@
+package jin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTableCopyOnWrite(t *testing.T) {
+	router := New()
+	router.GET("/users/:id", handlerTest1)
+	router.GET("/users/new", handlerTest1)
+	before := router.table()
+
+	router.GET("/users/:id/posts", handlerTest2)
+	router.POST("/users", handlerTest2)
+	assert.NotSame(t, before, router.table())
+
+	var paths []string
+	before.walk(func(route *routeEntry) {
+		paths = append(paths, route.info.Method+" "+route.info.Path)
+	})
+	assert.ElementsMatch(t, []string{"GET /users/:id", "GET /users/new"}, paths)
+	assert.Len(t, router.Routes(), 4)
+
+	// a conflicting route leaves the table as is
+	current := router.table()
+	assert.Panics(t, func() { router.GET("/users/:name", handlerTest1) })
+	assert.Same(t, current, router.table())
+	_, _, ok := router.MatchRoute(http.MethodGet, "/users/42/posts")
+	assert.True(t, ok)
+}
+
+func TestEngineRemoveRoute(t *testing.T) {
+	router := New()
+	router.GET("/users/:id<int>", func(c *Context) { c.String(http.StatusOK, "id") })
+	router.GET("/users/:id<[a-z0-9]+>", func(c *Context) { c.String(http.StatusOK, "slug") })
+	router.GET("/users/:name", func(c *Context) { c.String(http.StatusOK, "name") })
+	router.GET("/users/:id<int>/posts", handlerTest1).Name("posts")
+	router.POST("/users", handlerTest1)
+
+	assert.NoError(t, router.RemoveRoute(http.MethodGet, "/users/:name"))
+	assert.ErrorIs(t, router.RemoveRoute(http.MethodGet, "/users/:name"), ErrRouteNotFound)
+	assert.ErrorIs(t, router.RemoveRoute(http.MethodPut, "/users"), ErrRouteNotFound)
+	assert.Len(t, router.Routes(), 4)
+
+	for path, body := range map[string]string{"/users/42": "id", "/users/x42": "slug", "/users/X": "404 page not found"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, body, w.Body.String(), path)
+	}
+
+	_, err := router.URL("posts", "id", "1")
+	assert.NoError(t, err)
+	assert.NoError(t, router.RemoveRoute(http.MethodGet, "/users/:id<int>/posts"))
+	_, err = router.URL("posts", "id", "1")
+	assert.ErrorIs(t, err, ErrRouteNotFound)
+
+	assert.NoError(t, router.RemoveRoute(http.MethodPost, "/users"))
+	assert.Nil(t, router.table().trees.get(http.MethodPost))
+	// the route can be added again
+	router.POST("/users", handlerTest1)
+	_, _, ok := router.MatchRoute(http.MethodPost, "/users")
+	assert.True(t, ok)
+}
+
+func TestEngineAddRouteWhileServing(t *testing.T) {
+	router := New()
+	router.GET("/", func(c *Context) {})
+
+	// the pooled context has room for no parameter
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	router.GET("/a/:a/b/:b/c/:c/d/:d", func(c *Context) {
+		c.String(http.StatusOK, "%s%s%s%s", c.Param("a"), c.Param("b"), c.Param("c"), c.Param("d"))
+	})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/a/1/b/2/c/3/d/4", nil))
+	assert.Equal(t, "1234", w.Body.String())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/a/1/b/2/c/3/d/4", nil))
+				assert.Equal(t, http.StatusOK, w.Code)
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		path := fmt.Sprintf("/plugins/%d/:id", i)
+		router.GET(path, handlerTest1)
+		if i%2 == 0 {
+			assert.NoError(t, router.RemoveRoute(http.MethodGet, path))
+		}
+	}
+	wg.Wait()
+	assert.Len(t, router.Routes(), 52)
+}
+
+func TestMethodTreeStatic(t *testing.T) {
+	router := New()
+	for _, route := range githubAPI {
+		router.Handle(route.method, route.path, func(c *Context) {
+			c.String(http.StatusOK, c.FullPath())
+		})
+	}
+	for _, route := range githubAPI {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, route.path, nil))
+		assert.Equal(t, route.path, w.Body.String(), route.method+" "+route.path)
+	}
+
+	static := router.table().trees[http.MethodGet].staticRoutes()
+	assert.Contains(t, static, "/user/repos")
+	assert.NotContains(t, static, "/users/:user/repos")
+
+	// the static routes of a published table are left as is
+	router.GET("/user/installations", handlerTest1)
+	assert.NotContains(t, static, "/user/installations")
+	assert.Nil(t, router.table().trees[http.MethodGet].static)
+	assert.Contains(t, router.table().trees[http.MethodGet].staticRoutes(), "/user/installations")
+
+	// a static route is found, like in the tree, before the :params
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/:user/keys", nil))
+	assert.Equal(t, "/users/:user/keys", w.Body.String())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gists/starred", nil))
+	assert.Equal(t, "/gists/starred", w.Body.String())
+
+	assert.NoError(t, router.RemoveRoute(http.MethodGet, "/user/repos"))
+	assert.NotContains(t, router.table().trees[http.MethodGet].staticRoutes(), "/user/repos")
+	assert.Contains(t, router.table().trees[http.MethodGet].staticRoutes(), "/user/installations")
+}
+
+func TestRouteConfigureWhileServing(t *testing.T) {
+	router := New()
+	route := router.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "%v %v", c.Route().Meta["n"], len(c.Route().Tags))
+	})
+	router.Version("2", HeaderVersion("API-Version")).GET("/users/:id", handlerTest1).Meta("v", 2)
+	before := router.table()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+				assert.Equal(t, http.StatusOK, w.Code)
+				for _, info := range router.Routes() {
+					_ = info.Meta["n"]
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		route.Meta("n", i).Tags(fmt.Sprint(i))
+	}
+	route.Name("user")
+	wg.Wait()
+
+	// the tables published before are left as is
+	before.walk(func(entry *routeEntry) {
+		assert.Empty(t, entry.info.Tags)
+		assert.Empty(t, entry.info.Name)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, "99 100", w.Body.String())
+	url, err := router.URL("user", "id", "7")
+	assert.NoError(t, err)
+	assert.Equal(t, "/users/7", url)
+	for _, info := range router.Routes() {
+		if info.Version == "2" {
+			assert.Equal(t, map[string]any{"v": 2}, info.Meta)
+		}
+	}
+
+	// the removed routes are left as is
+	assert.NoError(t, router.RemoveRoute(http.MethodGet, "/users/:id"))
+	route.Meta("n", -1)
+	assert.Empty(t, router.Routes())
+}
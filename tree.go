@@ -6,8 +6,12 @@ package jin
 
 import (
 	"bytes"
+	"errors"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"unicode"
 	"unicode/utf8"
 
@@ -49,22 +53,78 @@ func (ps Params) ByName(name string) (va string) {
 	return
 }
 
+// Int returns the value of the first Param which key matches the given name as an
+// int, like the one of a :param<int>. It returns an error if there is no such Param
+// or if its value isn't an int.
+func (ps Params) Int(name string) (int, error) {
+	value, ok := ps.Get(name)
+	if !ok {
+		return 0, errors.New("param " + strconv.Quote(name) + " not found")
+	}
+	return strconv.Atoi(value)
+}
+
+// UUID returns the value of the first Param which key matches the given name as the
+// bytes of a UUID, like the one of a :param<uuid>. It returns an error if there is no
+// such Param or if its value isn't a UUID.
+func (ps Params) UUID(name string) ([16]byte, error) {
+	value, ok := ps.Get(name)
+	if !ok {
+		return [16]byte{}, errors.New("param " + strconv.Quote(name) + " not found")
+	}
+	uuid, err := parseUUID(value)
+	if err != nil {
+		return uuid, &strconv.NumError{Func: "parseUUID", Num: value, Err: err}
+	}
+	return uuid, nil
+}
+
 type methodTree struct {
-	method string
-	root   *node
+	root *node
+	// static holds the values of the routes without wildcards by path, which are
+	// looked up before walking the tree. It is built from the tree by the first
+	// lookup, so that the registrations don't have to copy it.
+	static     map[string]nodeValue
+	staticOnce sync.Once
 }
 
-type methodTrees []methodTree
+// methodTrees holds the route trees by HTTP method.
+type methodTrees map[string]*methodTree
 
 func (trees methodTrees) get(method string) *node {
-	for _, tree := range trees {
-		if tree.method == method {
-			return tree.root
-		}
+	if tree := trees[method]; tree != nil {
+		return tree.root
 	}
 	return nil
 }
 
+// getValue returns the handle registered for path, like node.getValue, the static
+// routes being found without walking the tree.
+func (tree *methodTree) getValue(path string, params *Params, skippedNodes *[]skippedNode, unescape bool) nodeValue {
+	if value, ok := tree.staticRoutes()[path]; ok {
+		return value
+	}
+	return tree.root.getValue(path, params, skippedNodes, unescape)
+}
+
+// staticRoutes returns tree.static, building it the first time.
+func (tree *methodTree) staticRoutes() map[string]nodeValue {
+	tree.staticOnce.Do(func() {
+		tree.static = make(map[string]nodeValue)
+		tree.root.walk(func(n *node) {
+			if n.handlers != nil && !strings.ContainsAny(n.fullPath, ":*") {
+				tree.static[n.fullPath] = nodeValue{
+					handlers: n.handlers,
+					fullPath: n.fullPath,
+					route:    n.route,
+					versions: n.versions,
+				}
+			}
+		})
+	})
+	return tree.static
+}
+
 func min(a, b int) int {
 	if a <= b {
 		return a
@@ -81,14 +141,28 @@ func longestCommonPrefix(a, b string) int {
 	return i
 }
 
-// addChild will add a child node, keeping wildcardChild at the end
+// addChild will add a child node, keeping the wildcard children at the end. As they
+// are tried from the last one, the :param without constraint comes first among them
+// and the ones with constraints follow in the reverse order of their registration.
 func (n *node) addChild(child *node) {
-	if n.wildChild && len(n.children) > 0 {
-		wildcardChild := n.children[len(n.children)-1]
-		n.children = append(n.children[:len(n.children)-1], child, wildcardChild)
-	} else {
-		n.children = append(n.children, child)
+	pos := len(n.children) - len(n.wildChildren())
+	if child.nType == param && child.constraint != nil &&
+		pos < len(n.children) && n.children[pos].nType == param && n.children[pos].constraint == nil {
+		pos++
+	}
+	n.children = slices.Insert(n.children, pos, child)
+}
+
+// wildChildren returns the wildcard children of n, the :params or the catch-all.
+func (n *node) wildChildren() []*node {
+	if !n.wildChild || len(n.children) == 0 {
+		return nil
 	}
+	i := len(n.children) - 1
+	for i > 0 && n.children[i-1].nType == param {
+		i--
+	}
+	return n.children[i:]
 }
 
 func countParams(path string) uint16 {
@@ -119,9 +193,23 @@ type node struct {
 	wildChild bool
 	nType     nodeType
 	priority  uint32
-	children  []*node // child nodes, at most 1 :param style node at the end of the array
+	children  []*node // child nodes, the :param style nodes at the end of the array
 	handlers  HandlersChain
 	fullPath  string
+	route     *routeEntry
+	// versions holds the routes of the versioned groups registered for the path,
+	// route being the first of them.
+	versions *versionSet
+	// constraint restricts the values matched by a :param node, if it has one.
+	constraint *paramConstraint
+}
+
+// walk calls fn for n and all its descendants, depth-first.
+func (n *node) walk(fn func(*node)) {
+	fn(n)
+	for _, child := range n.children {
+		child.walk(fn)
+	}
 }
 
 // Increments priority of the given child and reorders if necessary
@@ -147,17 +235,29 @@ func (n *node) incrementChildPrio(pos int) int {
 	return newPos
 }
 
-// addRoute adds a node with the given handle to the path.
+// addRoute adds a node with the given handle to the path and returns the node
+// holding the handle. The nodes it walks through are cloned first, so that n must
+// be a clone and the tree n was cloned from is left as is, see routeTable.
 // Not concurrency-safe!
-func (n *node) addRoute(path string, handlers HandlersChain) {
+func (n *node) addRoute(path string, handlers HandlersChain) *node {
+	leaf, added := n.insertRoute(path, handlers)
+	if !added {
+		panic("handlers are already registered for path '" + path + "'")
+	}
+	return leaf
+}
+
+// insertRoute is addRoute, except that it returns the node holding the handle
+// already registered for path, if any, leaving it as is.
+func (n *node) insertRoute(path string, handlers HandlersChain) (leaf *node, added bool) {
 	fullPath := path
 	n.priority++
 
 	// Empty tree
 	if len(n.path) == 0 && len(n.children) == 0 {
-		n.insertChild(path, fullPath, handlers)
+		leaf := n.insertChild(path, fullPath, handlers)
 		n.nType = root
-		return
+		return leaf, true
 	}
 
 	parentFullPathIndex := 0
@@ -180,6 +280,10 @@ walk:
 				handlers:  n.handlers,
 				priority:  n.priority - 1,
 				fullPath:  n.fullPath,
+				route:     n.route,
+				versions:  n.versions,
+				// a wildcard is never split, but keep the copy whole
+				constraint: n.constraint,
 			}
 
 			n.children = []*node{&child}
@@ -187,6 +291,8 @@ walk:
 			n.indices = bytesconv.BytesToString([]byte{n.path[i]})
 			n.path = path[:i]
 			n.handlers = nil
+			n.route = nil
+			n.versions = nil
 			n.wildChild = false
 			n.fullPath = fullPath[:parentFullPathIndex+i]
 		}
@@ -199,7 +305,7 @@ walk:
 			// '/' after param
 			if n.nType == param && c == '/' && len(n.children) == 1 {
 				parentFullPathIndex += len(n.path)
-				n = n.children[0]
+				n = n.cloneChild(0)
 				n.priority++
 				continue walk
 			}
@@ -208,6 +314,7 @@ walk:
 			for i, max := 0, len(n.indices); i < max; i++ {
 				if c == n.indices[i] {
 					parentFullPathIndex += len(n.path)
+					n.cloneChild(i)
 					i = n.incrementChildPrio(i)
 					n = n.children[i]
 					continue walk
@@ -225,20 +332,40 @@ walk:
 				n.incrementChildPrio(len(n.indices) - 1)
 				n = child
 			} else if n.wildChild {
-				// inserting a wildcard node, need to check if it conflicts with the existing wildcard
-				n = n.children[len(n.children)-1]
-				n.priority++
+				// inserting a wildcard node, need to check if it conflicts with the existing wildcards
+				parent := n
+				wildChildren := n.wildChildren()
+				for i, child := range wildChildren {
+					// Check if the wildcard matches
+					if len(path) >= len(child.path) && child.path == path[:len(child.path)] &&
+						// Adding a child to a catchAll is not possible
+						child.nType != catchAll &&
+						// Check for longer wildcard, e.g. :name and :names
+						(len(child.path) >= len(path) || path[len(child.path)] == '/') {
+						n = n.cloneChild(len(n.children) - len(wildChildren) + i)
+						n.priority++
+						continue walk
+					}
+				}
 
-				// Check if the wildcard matches
-				if len(path) >= len(n.path) && n.path == path[:len(n.path)] &&
-					// Adding a child to a catchAll is not possible
-					n.nType != catchAll &&
-					// Check for longer wildcard, e.g. :name and :names
-					(len(n.path) >= len(path) || path[len(n.path)] == '/') {
-					continue walk
+				// Several :params can share a segment as long as all of them but one
+				// have a constraint, they are tried in turn
+				if c == ':' {
+					wildcard, _, _ := findWildcard(path)
+					_, constraint := parseWildcard(wildcard, fullPath)
+					shared := true
+					for _, child := range wildChildren {
+						if child.nType != param || (constraint == nil && child.constraint == nil) {
+							shared = false
+						}
+					}
+					if shared {
+						return parent.insertChild(path, fullPath, handlers), true
+					}
 				}
 
 				// Wildcard conflict
+				n = wildChildren[len(wildChildren)-1]
 				pathSeg := path
 				if n.nType != catchAll {
 					pathSeg = strings.SplitN(pathSeg, "/", 2)[0]
@@ -251,17 +378,16 @@ walk:
 					"'")
 			}
 
-			n.insertChild(path, fullPath, handlers)
-			return
+			return n.insertChild(path, fullPath, handlers), true
 		}
 
 		// Otherwise add handle to current node
 		if n.handlers != nil {
-			panic("handlers are already registered for path '" + fullPath + "'")
+			return n, false
 		}
 		n.handlers = handlers
 		n.fullPath = fullPath
-		return
+		return n, true
 	}
 }
 
@@ -275,14 +401,22 @@ func findWildcard(path string) (wildcard string, i int, valid bool) {
 			continue
 		}
 
-		// Find end and check for invalid characters
+		// Find end and check for invalid characters, which are allowed in the
+		// constraint between angle brackets
 		valid = true
+		depth := 0
 		for end, c := range []byte(path[start+1:]) {
 			switch c {
 			case '/':
 				return path[start : start+1+end], start, valid
+			case '<':
+				depth++
+			case '>':
+				depth--
 			case ':', '*':
-				valid = false
+				if depth == 0 {
+					valid = false
+				}
 			}
 		}
 		return path[start:], start, valid
@@ -290,7 +424,7 @@ func findWildcard(path string) (wildcard string, i int, valid bool) {
 	return "", -1, false
 }
 
-func (n *node) insertChild(path string, fullPath string, handlers HandlersChain) {
+func (n *node) insertChild(path string, fullPath string, handlers HandlersChain) *node {
 	for {
 		// Find prefix until first wildcard
 		wildcard, i, valid := findWildcard(path)
@@ -308,6 +442,7 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 		if len(wildcard) < 2 {
 			panic("wildcards must be named with a non-empty name in path '" + fullPath + "'")
 		}
+		_, constraint := parseWildcard(wildcard, fullPath)
 
 		if wildcard[0] == ':' { // param
 			if i > 0 {
@@ -317,9 +452,10 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 			}
 
 			child := &node{
-				nType:    param,
-				path:     wildcard,
-				fullPath: fullPath,
+				nType:      param,
+				path:       wildcard,
+				fullPath:   fullPath,
+				constraint: constraint,
 			}
 			n.addChild(child)
 			n.wildChild = true
@@ -342,7 +478,7 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 
 			// Otherwise we're done. Insert the handle in the new leaf
 			n.handlers = handlers
-			return
+			return n
 		}
 
 		// catchAll
@@ -389,13 +525,14 @@ func (n *node) insertChild(path string, fullPath string, handlers HandlersChain)
 		}
 		n.children = []*node{child}
 
-		return
+		return child
 	}
 
 	// If no wildcard was found, simply insert the path and handle
 	n.path = path
 	n.handlers = handlers
 	n.fullPath = fullPath
+	return n
 }
 
 // nodeValue holds return values of (*Node).getValue method
@@ -404,12 +541,33 @@ type nodeValue struct {
 	params   *Params
 	tsr      bool
 	fullPath string
+	route    *routeEntry
+	versions *versionSet
 }
 
 type skippedNode struct {
 	path        string
 	node        *node
 	paramsCount int16
+	// children is the number of children of node left to try, its static children
+	// being skipped once backtracked to.
+	children int16
+}
+
+// backtrack pops the skipped nodes until the last one which can match path, and
+// truncates the params to their count there.
+func (value *nodeValue) backtrack(path string, skippedNodes *[]skippedNode) (skippedNode, bool) {
+	for length := len(*skippedNodes); length > 0; length-- {
+		skipped := (*skippedNodes)[length-1]
+		*skippedNodes = (*skippedNodes)[:length-1]
+		if strings.HasSuffix(skipped.path, path) {
+			if value.params != nil {
+				*value.params = (*value.params)[:skipped.paramsCount]
+			}
+			return skipped, true
+		}
+	}
+	return skippedNode{}, false
 }
 
 // Returns the handle registered with the given path (key). The values of
@@ -419,38 +577,49 @@ type skippedNode struct {
 // given path.
 func (n *node) getValue(path string, params *Params, skippedNodes *[]skippedNode, unescape bool) (value nodeValue) {
 	var globalParamsCount int16
+	if params != nil {
+		// the params of the host come first
+		globalParamsCount = int16(len(*params))
+	}
+	// skippedNodes is only a buffer, the nodes left by a previous lookup, maybe in
+	// another tree, must not be backtracked to
+	*skippedNodes = (*skippedNodes)[:0]
+
+	// backtracked is the number of children of n to try when it was backtracked to,
+	// or -1
+	backtracked := -1
 
 walk: // Outer loop for walking the tree
 	for {
 		prefix := n.path
+		indices, children := n.indices, n.children
+		if backtracked >= 0 {
+			indices, children = "", children[:backtracked]
+			backtracked = -1
+		}
 		if len(path) > len(prefix) {
 			if path[:len(prefix)] == prefix {
+				// the path to backtrack to, prefix included, without concatenating it
+				prefixedPath := path
 				path = path[len(prefix):]
 
 				// Try all the non-wildcard children first by matching the indices
 				idxc := path[0]
-				for i, c := range []byte(n.indices) {
+				for i, c := range []byte(indices) {
 					if c == idxc {
 						//  strings.HasPrefix(n.children[len(n.children)-1].path, ":") == n.wildChild
 						if n.wildChild {
 							index := len(*skippedNodes)
 							*skippedNodes = (*skippedNodes)[:index+1]
 							(*skippedNodes)[index] = skippedNode{
-								path: prefix + path,
-								node: &node{
-									path:      n.path,
-									wildChild: n.wildChild,
-									nType:     n.nType,
-									priority:  n.priority,
-									children:  n.children,
-									handlers:  n.handlers,
-									fullPath:  n.fullPath,
-								},
+								path:        prefixedPath,
+								node:        n,
 								paramsCount: globalParamsCount,
+								children:    int16(len(children)),
 							}
 						}
 
-						n = n.children[i]
+						n = children[i]
 						continue walk
 					}
 				}
@@ -459,18 +628,9 @@ walk: // Outer loop for walking the tree
 					// If the path at the end of the loop is not equal to '/' and the current node has no child nodes
 					// the current node needs to roll back to last valid skippedNode
 					if path != "/" {
-						for length := len(*skippedNodes); length > 0; length-- {
-							skippedNode := (*skippedNodes)[length-1]
-							*skippedNodes = (*skippedNodes)[:length-1]
-							if strings.HasSuffix(skippedNode.path, path) {
-								path = skippedNode.path
-								n = skippedNode.node
-								if value.params != nil {
-									*value.params = (*value.params)[:skippedNode.paramsCount]
-								}
-								globalParamsCount = skippedNode.paramsCount
-								continue walk
-							}
+						if skipped, ok := value.backtrack(path, skippedNodes); ok {
+							path, n, globalParamsCount, backtracked = skipped.path, skipped.node, skipped.paramsCount, int(skipped.children)
+							continue walk
 						}
 					}
 
@@ -481,8 +641,17 @@ walk: // Outer loop for walking the tree
 					return
 				}
 
-				// Handle wildcard child, which is always at the end of the array
-				n = n.children[len(n.children)-1]
+				// Handle wildcard child, which is always at the end of the array.
+				// The :params before it are tried next if it doesn't match.
+				if len(children) > 1 && children[len(children)-2].nType == param {
+					*skippedNodes = append(*skippedNodes, skippedNode{
+						path:        prefixedPath,
+						node:        n,
+						paramsCount: globalParamsCount,
+						children:    int16(len(children) - 1),
+					})
+				}
+				n = children[len(children)-1]
 				globalParamsCount++
 
 				switch n.nType {
@@ -496,6 +665,20 @@ walk: // Outer loop for walking the tree
 						end++
 					}
 
+					val := path[:end]
+					if unescape {
+						if v, err := url.QueryUnescape(val); err == nil {
+							val = v
+						}
+					}
+					if n.constraint != nil && !n.constraint.match(val) {
+						if skipped, ok := value.backtrack(path, skippedNodes); ok {
+							path, n, globalParamsCount, backtracked = skipped.path, skipped.node, skipped.paramsCount, int(skipped.children)
+							continue walk
+						}
+						return
+					}
+
 					// Save param value
 					if params != nil && cap(*params) > 0 {
 						if value.params == nil {
@@ -504,14 +687,12 @@ walk: // Outer loop for walking the tree
 						// Expand slice within preallocated capacity
 						i := len(*value.params)
 						*value.params = (*value.params)[:i+1]
-						val := path[:end]
-						if unescape {
-							if v, err := url.QueryUnescape(val); err == nil {
-								val = v
-							}
+						key := n.path[1:]
+						if n.constraint != nil {
+							key = n.constraint.key
 						}
 						(*value.params)[i] = Param{
-							Key:   n.path[1:],
+							Key:   key,
 							Value: val,
 						}
 					}
@@ -526,18 +707,31 @@ walk: // Outer loop for walking the tree
 
 						// ... but we can't
 						value.tsr = len(path) == end+1
+						if !value.tsr {
+							if skipped, ok := value.backtrack(path, skippedNodes); ok {
+								path, n, globalParamsCount, backtracked = skipped.path, skipped.node, skipped.paramsCount, int(skipped.children)
+								continue walk
+							}
+						}
 						return
 					}
 
 					if value.handlers = n.handlers; value.handlers != nil {
 						value.fullPath = n.fullPath
+						value.route, value.versions = n.route, n.versions
 						return
 					}
 					if len(n.children) == 1 {
 						// No handle found. Check if a handle for this path + a
 						// trailing slash exists for TSR recommendation
-						n = n.children[0]
-						value.tsr = (n.path == "/" && n.handlers != nil) || (n.path == "" && n.indices == "/")
+						child := n.children[0]
+						value.tsr = (child.path == "/" && child.handlers != nil) || (child.path == "" && child.indices == "/")
+					}
+					if !value.tsr {
+						if skipped, ok := value.backtrack(path, skippedNodes); ok {
+							path, n, globalParamsCount, backtracked = skipped.path, skipped.node, skipped.paramsCount, int(skipped.children)
+							continue walk
+						}
 					}
 					return
 
@@ -564,6 +758,7 @@ walk: // Outer loop for walking the tree
 
 					value.handlers = n.handlers
 					value.fullPath = n.fullPath
+					value.route, value.versions = n.route, n.versions
 					return
 
 				default:
@@ -576,18 +771,9 @@ walk: // Outer loop for walking the tree
 			// If the current path does not equal '/' and the node does not have a registered handle and the most recently matched node has a child node
 			// the current node needs to roll back to last valid skippedNode
 			if n.handlers == nil && path != "/" {
-				for length := len(*skippedNodes); length > 0; length-- {
-					skippedNode := (*skippedNodes)[length-1]
-					*skippedNodes = (*skippedNodes)[:length-1]
-					if strings.HasSuffix(skippedNode.path, path) {
-						path = skippedNode.path
-						n = skippedNode.node
-						if value.params != nil {
-							*value.params = (*value.params)[:skippedNode.paramsCount]
-						}
-						globalParamsCount = skippedNode.paramsCount
-						continue walk
-					}
+				if skipped, ok := value.backtrack(path, skippedNodes); ok {
+					path, n, globalParamsCount, backtracked = skipped.path, skipped.node, skipped.paramsCount, int(skipped.children)
+					continue walk
 				}
 				//	n = latestNode.children[len(latestNode.children)-1]
 			}
@@ -595,6 +781,7 @@ walk: // Outer loop for walking the tree
 			// Check if this node has a handle registered.
 			if value.handlers = n.handlers; value.handlers != nil {
 				value.fullPath = n.fullPath
+				value.route, value.versions = n.route, n.versions
 				return
 			}
 
@@ -613,9 +800,9 @@ walk: // Outer loop for walking the tree
 
 			// No handle found. Check if a handle for this path + a
 			// trailing slash exists for trailing slash recommendation
-			for i, c := range []byte(n.indices) {
+			for i, c := range []byte(indices) {
 				if c == '/' {
-					n = n.children[i]
+					n = children[i]
 					value.tsr = (len(n.path) == 1 && n.handlers != nil) ||
 						(n.nType == catchAll && n.children[0].handlers != nil)
 					return
@@ -633,18 +820,9 @@ walk: // Outer loop for walking the tree
 
 		// roll back to last valid skippedNode
 		if !value.tsr && path != "/" {
-			for length := len(*skippedNodes); length > 0; length-- {
-				skippedNode := (*skippedNodes)[length-1]
-				*skippedNodes = (*skippedNodes)[:length-1]
-				if strings.HasSuffix(skippedNode.path, path) {
-					path = skippedNode.path
-					n = skippedNode.node
-					if value.params != nil {
-						*value.params = (*value.params)[:skippedNode.paramsCount]
-					}
-					globalParamsCount = skippedNode.paramsCount
-					continue walk
-				}
+			if skipped, ok := value.backtrack(path, skippedNodes); ok {
+				path, n, globalParamsCount, backtracked = skipped.path, skipped.node, skipped.paramsCount, int(skipped.children)
+				continue walk
 			}
 		}
 
@@ -422,6 +422,82 @@ func TestTreeWildcardConflict(t *testing.T) {
 	testRoutes(t, routes)
 }
 
+func TestTreeConstrainedWildcard(t *testing.T) {
+	tree := &node{}
+
+	routes := [...]string{
+		"/users/:id<int>",
+		"/users/:id<uuid>/posts",
+		"/users/:name",
+		"/users/new",
+		"/posts/:slug<[a-z-]+>",
+		"/posts/:id<int>/comments/:cid<int>",
+		"/posts/:id<int>/edit",
+		"/tags/:tag<[a-z]{2,3}>",
+		"/tags/:tag<(a|b):(c|d)>/x",
+	}
+	for _, route := range routes {
+		tree.addRoute(route, fakeHandler(route))
+	}
+
+	checkRequests(t, tree, testRequests{
+		{"/users/42", false, "/users/:id<int>", Params{Param{"id", "42"}}},
+		{"/users/new", false, "/users/new", nil},
+		{"/users/john", false, "/users/:name", Params{Param{"name", "john"}}},
+		{"/users/123e4567-e89b-12d3-a456-426614174000/posts", false, "/users/:id<uuid>/posts", Params{Param{"id", "123e4567-e89b-12d3-a456-426614174000"}}},
+		{"/users/42/posts", true, "", Params{Param{"name", "42"}}},
+		{"/posts/hello-world", false, "/posts/:slug<[a-z-]+>", Params{Param{"slug", "hello-world"}}},
+		{"/posts/Hello", true, "", nil},
+		{"/posts/42/edit", false, "/posts/:id<int>/edit", Params{Param{"id", "42"}}},
+		{"/posts/42/comments/7", false, "/posts/:id<int>/comments/:cid<int>", Params{Param{"id", "42"}, Param{"cid", "7"}}},
+		{"/posts/42/comments/x", true, "", Params{Param{"id", "42"}}},
+		{"/posts/x/edit", true, "", Params{}},
+		{"/tags/go", false, "/tags/:tag<[a-z]{2,3}>", Params{Param{"tag", "go"}}},
+		{"/tags/rust", true, "", nil},
+		{"/tags/a:d/x", false, "/tags/:tag<(a|b):(c|d)>/x", Params{Param{"tag", "a:d"}}},
+	})
+
+	checkPriorities(t, tree)
+}
+
+func TestTreeBacktrackAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+	tree := &node{}
+	for _, route := range []string{"/users/:id<int>/posts", "/users/:name/friends", "/users/new", "/users/:id<int>"} {
+		tree.addRoute(route, fakeHandler(route))
+	}
+	params := make(Params, 0, 1)
+	skippedNodes := make([]skippedNode, 0, 4)
+	for _, path := range []string{"/users/john/friends", "/users/new", "/users/42"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			params = params[:0]
+			if tree.getValue(path, &params, &skippedNodes, false).handlers == nil {
+				t.Fatal("no route for " + path)
+			}
+		})
+		assert.Zero(t, allocs, path)
+	}
+}
+
+func TestTreeConstrainedWildcardConflict(t *testing.T) {
+	routes := []testRoute{
+		{"/users/:id<int>", false},
+		{"/users/:name", false},
+		{"/users/:slug<[a-z]+>", false},
+		{"/users/:other", true},
+		{"/files/*path", false},
+		{"/files/:id<int>", true},
+		{"/bad/:id<int", true},
+		{"/bad/:<int>", true},
+		{"/bad/:id<>", true},
+		{"/bad/:id<[a-z>", true},
+		{"/bad/*path<int>", true},
+	}
+	testRoutes(t, routes)
+}
+
 func TestCatchAllAfterSlash(t *testing.T) {
 	routes := []testRoute{
 		{"/non-leading-*catchall", true},
@@ -967,4 +1043,27 @@ func TestParams(t *testing.T) {
 		assert.Equal(t, "22", ps.ByName("age"))
 		assert.Equal(t, "", ps.ByName("sex"))
 	})
+
+	t.Run("TestInt", func(t *testing.T) {
+		age, err := ps.Int("age")
+		assert.NoError(t, err)
+		assert.Equal(t, 22, age)
+
+		_, err = ps.Int("name")
+		assert.Error(t, err)
+		_, err = ps.Int("sex")
+		assert.Error(t, err)
+	})
+
+	t.Run("TestUUID", func(t *testing.T) {
+		ps := Params{Param{Key: "id", Value: "123e4567-E89B-12d3-a456-426614174000"}}
+		id, err := ps.UUID("id")
+		assert.NoError(t, err)
+		assert.Equal(t, [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}, id)
+
+		_, err = Params{Param{Key: "id", Value: "123e4567e89b12d3a456426614174000"}}.UUID("id")
+		assert.Error(t, err)
+		_, err = ps.UUID("sex")
+		assert.Error(t, err)
+	})
 }
@@ -0,0 +1,250 @@
+package jin
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/juanjiTech/inject/v2"
+)
+
+// TypedHandler is the HandlerFunc returned by Typed. It exposes the request and
+// response types of the endpoint, for route introspection and documentation.
+type TypedHandler interface {
+	inject.FastInvoker
+	// RequestType returns the type the request is bound to.
+	RequestType() reflect.Type
+	// ResponseType returns the type of the rendered response.
+	ResponseType() reflect.Type
+}
+
+type typedHandler[Req, Resp any] func(*Context)
+
+var _ TypedHandler = typedHandler[struct{}, struct{}](nil)
+
+// Invoke implements inject.FastInvoker.
+func (h typedHandler[Req, Resp]) Invoke(args []any) ([]reflect.Value, error) {
+	h(args[0].(*Context))
+	return nil, nil
+}
+
+// RequestType implements TypedHandler.
+func (h typedHandler[Req, Resp]) RequestType() reflect.Type {
+	return reflect.TypeFor[Req]()
+}
+
+// ResponseType implements TypedHandler.
+func (h typedHandler[Req, Resp]) ResponseType() reflect.Type {
+	return reflect.TypeFor[Resp]()
+}
+
+// Typed returns a handler calling fn with the request bound to Req and rendering the
+// Resp it returns, like the return value of a final handler. It is called without
+// reflection. The fields of a struct Req are bound according to their tags: `uri` for
+// the path parameters, `query` for the query string and `header` for the headers,
+// while the body is decoded as JSON into the other fields. A binding failure is recorded as an
+// ErrorTypeBind error and an error returned by fn is recorded like the one of any
+// handler, the chain is aborted in both cases.
+//
+//	type CreateUser struct {
+//	    OrgID string `uri:"org"`
+//	    Token string `header:"X-Token"`
+//	    Name  string `json:"name"`
+//	}
+//
+//	router.POST("/orgs/:org/users", jin.Typed(func(c *jin.Context, req CreateUser) (UserDTO, error) {
+//	    return users.Create(c, req)
+//	}))
+func Typed[Req, Resp any](fn func(c *Context, req Req) (Resp, error)) TypedHandler {
+	if fn == nil {
+		panic("handler can not be nil")
+	}
+	b := newBinder(reflect.TypeFor[Req]())
+	resultType := reflect.TypeOf((func() Resp)(nil))
+	return typedHandler[Req, Resp](func(c *Context) {
+		var req Req
+		if err := b.bind(c, reflect.ValueOf(&req).Elem()); err != nil {
+			_ = c.Error(err).SetType(ErrorTypeBind)
+			c.Abort()
+			return
+		}
+		resp, err := fn(c, req)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		c.renderResult(resultType, []reflect.Value{reflect.ValueOf(&resp).Elem()})
+	})
+}
+
+// binder binds requests to values of a given type, see Typed.
+type binder struct {
+	// fields are the tagged fields of a struct type.
+	fields []boundField
+}
+
+type boundField struct {
+	index []int
+	// source is the tag telling where the value comes from, and key its value.
+	source string
+	key    string
+}
+
+var bindSources = []string{"uri", "query", "header"}
+
+func newBinder(t reflect.Type) *binder {
+	b := &binder{}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		b.addFields(t, nil)
+	}
+	return b
+}
+
+func (b *binder) addFields(t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		tagged := false
+		for _, source := range bindSources {
+			if key, ok := field.Tag.Lookup(source); ok && key != "-" {
+				b.fields = append(b.fields, boundField{index: fieldIndex, source: source, key: key})
+				tagged = true
+				break
+			}
+		}
+		if !tagged && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			b.addFields(field.Type, fieldIndex)
+		}
+	}
+}
+
+func (b *binder) bind(c *Context, v reflect.Value) error {
+	if err := bindBody(c.Request, v.Addr().Interface()); err != nil {
+		return err
+	}
+	if len(b.fields) == 0 {
+		return nil
+	}
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	for _, f := range b.fields {
+		// the tagged fields only come from their source, not from the body
+		field := v.FieldByIndex(f.index)
+		field.SetZero()
+		var values []string
+		switch f.source {
+		case "uri":
+			if value, ok := c.Params.Get(f.key); ok {
+				values = []string{value}
+			}
+		case "query":
+			values = c.QueryArray(f.key)
+		case "header":
+			values = c.Request.Header.Values(f.key)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if err := setField(field, values); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", f.source, f.key, err)
+		}
+	}
+	return nil
+}
+
+// bindBody decodes the JSON body of the request into obj, if there is one.
+func bindBody(req *http.Request, obj any) error {
+	if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+		return nil
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return err
+		}
+		if mediaType != MIMEJSON && !strings.HasSuffix(mediaType, "+json") {
+			return fmt.Errorf("unsupported content type %q", mediaType)
+		}
+	}
+	if err := json.NewDecoder(req.Body).Decode(obj); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setField parses values into v, the first one unless v is a slice.
+func setField(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setField(v.Elem(), values)
+	}
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+	if v.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setField(slice.Index(i), []string{value}); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	value := values[0]
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %v", v.Type())
+	}
+	return nil
+}
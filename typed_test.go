@@ -0,0 +1,126 @@
+package jin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedPage struct {
+	Size int `query:"size"`
+}
+
+type typedCreateUser struct {
+	typedPage
+	Org     string    `uri:"org"`
+	Token   *string   `header:"X-Token"`
+	Tags    []string  `query:"tag"`
+	Since   time.Time `query:"since"`
+	Name    string    `json:"name"`
+	Ignored string    `query:"-"`
+}
+
+type typedUser struct {
+	Org  string   `json:"org"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+	Size int      `json:"size"`
+}
+
+func TestTyped(t *testing.T) {
+	var got typedCreateUser
+	handler := Typed(func(c *Context, req typedCreateUser) (*typedUser, error) {
+		got = req
+		if req.Name == "" {
+			return nil, errors.New("name required")
+		}
+		c.Status(http.StatusCreated)
+		return &typedUser{Org: req.Org, Name: req.Name, Tags: req.Tags, Size: req.Size}, nil
+	})
+	assert.Equal(t, reflect.TypeOf(typedCreateUser{}), handler.RequestType())
+	assert.Equal(t, reflect.TypeOf(&typedUser{}), handler.ResponseType())
+
+	router := New()
+	router.POST("/orgs/:org/users", handler)
+	assert.NoError(t, router.Validate())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/orgs/jin/users?tag=a&tag=b&size=10&since=2024-01-02T00:00:00Z&-=x",
+		strings.NewReader(`{"name":"gopher"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Token", "secret")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"org":"jin","name":"gopher","tags":["a","b"],"size":10}`, w.Body.String())
+	if assert.NotNil(t, got.Token) {
+		assert.Equal(t, "secret", *got.Token)
+	}
+	assert.Equal(t, 2024, got.Since.Year())
+	assert.Empty(t, got.Ignored)
+
+	// the tagged fields can't be set through the body
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/orgs/jin/users",
+		strings.NewReader(`{"name":"gopher","Org":"admin","Token":"forged","Tags":["x"],"Size":5}`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, `{"org":"jin","name":"gopher","tags":null,"size":0}`, w.Body.String())
+	assert.Nil(t, got.Token)
+
+	// the error of the handler
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/orgs/jin/users", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// binding errors
+	for _, tt := range []struct {
+		url, contentType, body string
+	}{
+		{"/orgs/jin/users?size=ten", "", ""},
+		{"/orgs/jin/users", "application/json", `{"name":`},
+		{"/orgs/jin/users", "text/plain", "gopher"},
+	} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.url+" "+tt.contentType)
+	}
+}
+
+func TestTypedNonStruct(t *testing.T) {
+	router := New()
+	router.PUT("/sum", Typed(func(c *Context, numbers []int) (int, error) {
+		sum := 0
+		for _, n := range numbers {
+			sum += n
+		}
+		return sum, nil
+	}))
+	router.GET("/ptr", Typed(func(c *Context, page *typedPage) (string, error) {
+		return "size " + c.Query("size"), nil
+	}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/sum", strings.NewReader(`[1, 2, 3]`))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "6", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/ptr?size=3", nil)
+	req.Header.Set("Accept", MIMEPlain)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "size 3", w.Body.String())
+
+	assert.Panics(t, func() {
+		Typed[string, string](nil)
+	})
+}
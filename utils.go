@@ -6,9 +6,14 @@ import (
 	"path"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 )
 
+// H is a shortcut for map[string]any
+type H map[string]any
+
 func resolveAddress(addr []string) string {
 	switch len(addr) {
 	case 0:
@@ -29,6 +34,9 @@ func nameOfFunction(f any) string {
 	if f == nil {
 		return "nil"
 	}
+	if p, ok := f.(errorPassthrough); ok {
+		f = p.handler
+	}
 	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
 }
 
@@ -55,6 +63,41 @@ func joinPaths(absolutePath, relativePath string) string {
 	return finalPath
 }
 
+// parseAccept returns the media ranges of an Accept header ordered by preference.
+func parseAccept(acceptHeader string) []string {
+	if acceptHeader == "" {
+		return nil
+	}
+	parts := strings.Split(acceptHeader, ",")
+	out := make([]string, 0, len(parts))
+	weights := make(map[string]float64, len(parts))
+	for _, part := range parts {
+		part, params, _ := strings.Cut(part, ";")
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(k) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		if weight <= 0 {
+			continue
+		}
+		weights[part] = weight
+		out = append(out, part)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return weights[out[i]] > weights[out[j]]
+	})
+	return out
+}
+
 // ordinalize ordinalizes the number by adding the ordinal to the number.
 func ordinalize(number int) string {
 	abs := int(math.Abs(float64(number)))
@@ -65,3 +65,10 @@ func TestOrdinalize(t *testing.T) {
 	assert.Equal(t, "23rd", ordinalize(23))
 	assert.Equal(t, "101st", ordinalize(101))
 }
+
+func TestParseAccept(t *testing.T) {
+	assert.Nil(t, parseAccept(""))
+	assert.Equal(t, []string{"text/html", "application/json", "*/*"},
+		parseAccept("*/*;q=0.1, application/json;q=0.5,text/html"))
+	assert.Equal(t, []string{"text/plain"}, parseAccept("text/plain, application/xml;q=0, ,"))
+}
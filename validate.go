@@ -0,0 +1,126 @@
+package jin
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// DependencyError reports a handler parameter that can't be injected, see Engine.Validate.
+type DependencyError struct {
+	// Route is the method and path of the route, or NoRoute/NoMethod.
+	Route string
+	// Handler is the name of the handler and Index its position in the chain.
+	Handler string
+	Index   int
+	// Type is the type which can't be resolved.
+	Type reflect.Type
+	// Provider is the name of the provider needing Type, if the handler doesn't
+	// ask for it directly.
+	Provider string
+}
+
+// Error implements the error interface.
+func (e *DependencyError) Error() string {
+	msg := fmt.Sprintf("%s: the %s handler %s needs %v", e.Route, ordinalize(e.Index), e.Handler, e.Type)
+	if e.Provider != "" {
+		msg = fmt.Sprintf("%s: the %s handler %s needs %v through provider %s", e.Route, ordinalize(e.Index), e.Handler, e.Type, e.Provider)
+	}
+	return msg + ", which is neither mapped, provided nor returned by a previous handler"
+}
+
+// Validate checks that the parameters of every handler of the registered routes can be
+// injected, by a value mapped in the engine, a provider, the values Jin maps for every
+// request or a value returned by a previous handler in the chain. The dependencies of
+// the providers are checked the same way. It returns all the unresolvable dependencies
+// joined, as *DependencyError, or nil. Run calls it before serving.
+func (engine *Engine) Validate() error {
+	var errs []error
+	engine.table().walk(func(entry *routeEntry) {
+		route := entry.info.Method + " " + entry.info.Path
+		if entry.info.Host != "" {
+			route = entry.info.Host + " " + route
+		}
+		if entry.info.Version != "" {
+			route += " (version " + entry.info.Version + ")"
+		}
+		errs = append(errs, engine.validateChain(route, entry.info.HandlersChain)...)
+	})
+	if engine.noRoute != nil {
+		errs = append(errs, engine.validateChain("NoRoute", engine.allNoRoute)...)
+	}
+	if engine.noMethod != nil {
+		errs = append(errs, engine.validateChain("NoMethod", engine.allNoMethod)...)
+	}
+	return errors.Join(errs...)
+}
+
+func (engine *Engine) validateChain(route string, handlers HandlersChain) []error {
+	var errs []error
+	available := append([]reflect.Type(nil), requestScopedTypes...)
+	for i, h := range handlers {
+		h, _ = unwrapHandler(h)
+		if h == nil {
+			continue
+		}
+		fnType := reflect.TypeOf(h)
+		if fnType.Kind() != reflect.Func {
+			continue
+		}
+		for j := 0; j < fnType.NumIn(); j++ {
+			if missing, p := engine.missingDependency(fnType.In(j), available, nil); missing != nil {
+				errs = append(errs, &DependencyError{
+					Route:    route,
+					Handler:  nameOfFunction(h),
+					Index:    i,
+					Type:     missing,
+					Provider: p,
+				})
+			}
+		}
+		for j := 0; j < fnType.NumOut(); j++ {
+			available = append(available, fnType.Out(j))
+		}
+	}
+	return errs
+}
+
+// missingDependency returns the first type t depends on which can't be resolved with
+// the available request values, along with the name of the provider needing it.
+func (engine *Engine) missingDependency(t reflect.Type, available []reflect.Type, visited map[*provider]bool) (reflect.Type, string) {
+	for _, a := range available {
+		if a == t || (t.Kind() == reflect.Interface && a.Implements(t)) {
+			return nil, ""
+		}
+	}
+	if engine.Injector.Value(t).IsValid() {
+		return nil, ""
+	}
+	p := engine.providers[t]
+	if p == nil {
+		return t, ""
+	}
+	if visited[p] {
+		// a cycle is reported by the provider at request time
+		return nil, ""
+	}
+	if visited == nil {
+		visited = make(map[*provider]bool)
+	}
+	visited[p] = true
+	if p.lifetime == Singleton {
+		// singletons only see the engine, registration rejected request-scoped dependencies
+		available = nil
+	}
+
+	fnType := reflect.TypeOf(p.fn)
+	for i := 0; i < fnType.NumIn(); i++ {
+		if missing, name := engine.missingDependency(fnType.In(i), available, visited); missing != nil {
+			if name == "" {
+				name = nameOfFunction(p.fn)
+			}
+			return missing, name
+		}
+	}
+	return nil, ""
+}
@@ -0,0 +1,57 @@
+package jin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineValidate(t *testing.T) {
+	engine := New()
+	engine.Map(&testDB{})
+	engine.Provide(func(db *testDB, s string) *testRepo { return nil })
+	engine.ProvideWith(Singleton, func(db *testDB) *testService { return nil })
+
+	engine.Use(func(c *Context) {})
+	engine.GET("/ok", func() string { return "" }, func(w http.ResponseWriter, r *http.Request, ctx context.Context, s string, repo *testRepo, svc *testService) {
+	})
+	engine.GET("/passed", PassErrors(func() error { return nil }), func(err error) {})
+	assert.NoError(t, engine.Validate())
+
+	engine.POST("/missing", func(db *testDB) {}, func(c *Context, tx *testTx) {})
+	engine.PUT("/provider", func(repo *testRepo) {})
+	engine.NoRoute(func(l *testLogger) {})
+
+	err := engine.Validate()
+	var errs []*DependencyError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var depErr *DependencyError
+		assert.True(t, errors.As(e, &depErr))
+		errs = append(errs, depErr)
+	}
+	if assert.Len(t, errs, 3) {
+		assert.Equal(t, "POST /missing", errs[0].Route)
+		assert.Equal(t, 2, errs[0].Index)
+		assert.Equal(t, reflect.TypeOf(&testTx{}), errs[0].Type)
+		assert.Empty(t, errs[0].Provider)
+
+		assert.Equal(t, "PUT /provider", errs[1].Route)
+		assert.Equal(t, reflect.TypeOf(""), errs[1].Type)
+		assert.Equal(t, "github.com/juanjiTech/jin.TestEngineValidate.func1", errs[1].Provider)
+		assert.Equal(t, "PUT /provider: the 1st handler github.com/juanjiTech/jin.TestEngineValidate.func10 needs string through provider github.com/juanjiTech/jin.TestEngineValidate.func1, which is neither mapped, provided nor returned by a previous handler", errs[1].Error())
+
+		assert.Equal(t, "NoRoute", errs[2].Route)
+	}
+}
+
+func TestEngineRunValidates(t *testing.T) {
+	engine := New()
+	engine.GET("/", func(tx *testTx) {})
+	err := engine.Run(":0")
+	var depErr *DependencyError
+	assert.True(t, errors.As(err, &depErr))
+}
@@ -0,0 +1,255 @@
+package jin
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// VersionSelector reads the API version a request asks for, see RouterGroup.Version.
+type VersionSelector interface {
+	// Version returns the version req asks for, or "" if it asks for none.
+	Version(req *http.Request) string
+	// Header returns the request header the version is read from, which the
+	// versioned routes list in the Vary header of their responses.
+	Header() string
+}
+
+type headerVersion string
+
+// HeaderVersion returns a VersionSelector reading the version in the request header
+// name, like "API-Version: 2".
+func HeaderVersion(name string) VersionSelector {
+	return headerVersion(http.CanonicalHeaderKey(name))
+}
+
+func (h headerVersion) Version(req *http.Request) string {
+	return strings.TrimSpace(req.Header.Get(string(h)))
+}
+
+func (h headerVersion) Header() string {
+	return string(h)
+}
+
+type mediaTypeVersion string
+
+// MediaTypeVersion returns a VersionSelector reading the version in the vendor media
+// types of the Accept header, like "application/vnd.acme.v2+json" for the vendor
+// "acme". The first media type of the vendor is used, whatever its quality.
+func MediaTypeVersion(vendor string) VersionSelector {
+	return mediaTypeVersion("vnd." + strings.ToLower(vendor) + ".v")
+}
+
+func (m mediaTypeVersion) Version(req *http.Request) string {
+	prefix := string(m)
+	for _, accept := range req.Header.Values("Accept") {
+		for accept != "" {
+			var mediaRange string
+			mediaRange, accept, _ = strings.Cut(accept, ",")
+			mediaType, _, _ := strings.Cut(mediaRange, ";")
+			_, subtype, _ := strings.Cut(strings.TrimSpace(mediaType), "/")
+			if len(subtype) <= len(prefix) || !strings.EqualFold(subtype[:len(prefix)], prefix) {
+				continue
+			}
+			if version, _, _ := strings.Cut(subtype[len(prefix):], "+"); version != "" {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+func (m mediaTypeVersion) Header() string {
+	return "Accept"
+}
+
+// Version returns a router group whose routes serve version of the API, as asked for
+// by the requests through selectors. Several versions of a route can be registered
+// for the same method and path, along with one outside of a versioned group.
+//
+//	v1 := router.Group("/api").Version("1", jin.HeaderVersion("API-Version"), jin.MediaTypeVersion("acme"))
+//	v1.GET("/users/:id", getUserV1)
+//	v2 := router.Group("/api").Version("2", jin.HeaderVersion("API-Version"), jin.MediaTypeVersion("acme"))
+//	v2.GET("/users/:id", getUserV2)
+//
+// The version asked for is the one returned by the first selector returning one. A
+// request is handled by the route of that version, or else of the highest version
+// below it, or else of Engine.DefaultVersion, or else by the route registered outside
+// of a versioned group. It is not found if there is none. The versions are compared
+// by their dot separated numbers, ignoring a leading "v". The headers read by the
+// selectors are added to the Vary header of the responses.
+func (group *RouterGroup) Version(version string, selectors ...VersionSelector) *RouterGroup {
+	if version == "" {
+		panic("version can not be empty")
+	}
+	if len(selectors) == 0 {
+		panic("there must be at least one version selector")
+	}
+	child := group.Group("")
+	child.version = version
+	child.versionSelectors = selectors
+	return child
+}
+
+// versionSet holds the routes registered for a method and path when at least one of
+// them is versioned. It is never modified once in a published routeTable.
+type versionSet struct {
+	// routes are in the order of their registrations.
+	routes    []*routeEntry
+	selectors []VersionSelector
+	// vary is added to the Vary header of the responses.
+	vary string
+}
+
+// insertEntry adds route to the tree of root, next to the routes registered for the
+// same path in other versions, and returns the node holding it. The node of a
+// versioned route always gets a versionSet, even if it is the only route of the
+// path, so that the requests are matched against its version.
+func (root *node) insertEntry(route *routeEntry) *node {
+	leaf, added := root.insertRoute(route.info.Path, route.info.HandlersChain)
+	var routes []*routeEntry
+	if added {
+		leaf.route = route
+		if route.info.Version == "" {
+			return leaf
+		}
+	} else {
+		routes = leaf.entries()
+	}
+
+	for _, other := range routes {
+		if sameVersion(other.info.Version, route.info.Version) {
+			if route.info.Version == "" {
+				panic("handlers are already registered for path '" + route.info.Path + "'")
+			}
+			panic("handlers are already registered for path '" + route.info.Path + "' and version '" + route.info.Version + "'")
+		}
+	}
+	set := &versionSet{routes: append(routes[:len(routes):len(routes)], route)}
+	headers := make([]string, 0, 2)
+	for _, r := range set.routes {
+		for _, selector := range r.group.versionSelectors {
+			if !slices.Contains(set.selectors, selector) {
+				set.selectors = append(set.selectors, selector)
+			}
+			if header := selector.Header(); !slices.Contains(headers, header) {
+				headers = append(headers, header)
+			}
+		}
+	}
+	set.vary = strings.Join(headers, ", ")
+	leaf.versions = set
+	return leaf
+}
+
+// entries returns the routes held by n, all their versions included.
+func (n *node) entries() []*routeEntry {
+	if n.versions != nil {
+		return n.versions.routes
+	}
+	return []*routeEntry{n.route}
+}
+
+// pick returns the route of the version req asks for, as documented by
+// RouterGroup.Version, or nil if there is none.
+func (set *versionSet) pick(req *http.Request, defaultVersion string) *routeEntry {
+	requested := ""
+	for _, selector := range set.selectors {
+		if requested = selector.Version(req); requested != "" {
+			break
+		}
+	}
+	i := pickVersion(len(set.routes), func(i int) string {
+		return set.routes[i].info.Version
+	}, requested, defaultVersion)
+	if i < 0 {
+		return nil
+	}
+	return set.routes[i]
+}
+
+// PickVersion returns the route of routes, registered for the same method and path,
+// which handles the requests asking for the version requested, as documented by
+// RouterGroup.Version, or false if there is none. requested is "" for the requests
+// asking for no version.
+func PickVersion(routes []RouteInfo, requested, defaultVersion string) (RouteInfo, bool) {
+	i := pickVersion(len(routes), func(i int) string {
+		return routes[i].Version
+	}, requested, defaultVersion)
+	if i < 0 {
+		return RouteInfo{}, false
+	}
+	return routes[i], true
+}
+
+// pickVersion returns the index of the version picked for requested among the n
+// versions returned by version, or -1.
+func pickVersion(n int, version func(i int) string, requested, defaultVersion string) int {
+	below, fallback, unversioned := -1, -1, -1
+	for i := 0; i < n; i++ {
+		v := version(i)
+		if v == "" {
+			unversioned = i
+			continue
+		}
+		if requested != "" {
+			switch c := compareVersions(v, requested); {
+			case c == 0:
+				return i
+			case c < 0 && (below < 0 || compareVersions(v, version(below)) > 0):
+				below = i
+			}
+		}
+		if defaultVersion != "" && compareVersions(v, defaultVersion) == 0 {
+			fallback = i
+		}
+	}
+	switch {
+	case below >= 0:
+		return below
+	case fallback >= 0:
+		return fallback
+	}
+	return unversioned
+}
+
+// compareVersions compares the versions a and b by their dot separated parts,
+// numerically when both parts are numbers. A leading "v" is ignored and the missing
+// parts are zeros, so that "v2" and "2.0" are the same version.
+func compareVersions(a, b string) int {
+	a = strings.TrimPrefix(strings.TrimPrefix(a, "v"), "V")
+	b = strings.TrimPrefix(strings.TrimPrefix(b, "v"), "V")
+	for a != "" || b != "" {
+		var pa, pb string
+		pa, a, _ = strings.Cut(a, ".")
+		pb, b, _ = strings.Cut(b, ".")
+		if pa == "" {
+			pa = "0"
+		}
+		if pb == "" {
+			pb = "0"
+		}
+		na, errA := strconv.ParseUint(pa, 10, 64)
+		nb, errB := strconv.ParseUint(pb, 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case pa != pb:
+			return strings.Compare(pa, pb)
+		}
+	}
+	return 0
+}
+
+func sameVersion(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	return compareVersions(a, b) == 0
+}
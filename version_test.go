@@ -0,0 +1,182 @@
+package jin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterGroupVersion(t *testing.T) {
+	router := New()
+	selectors := []VersionSelector{HeaderVersion("api-version"), MediaTypeVersion("acme")}
+	api := router.Group("/api")
+	api.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "unversioned %s", c.Param("id"))
+	})
+	for _, version := range []string{"1", "2", "v3.1"} {
+		api.Version(version, selectors...).Group("/users").GET("/:id", func(c *Context) {
+			c.String(http.StatusOK, "%s %s %s", version, c.Param("id"), c.Route().Version)
+		})
+	}
+	api.Version("2", selectors...).GET("/items", func(c *Context) {
+		c.String(http.StatusOK, "items")
+	})
+
+	tests := []struct {
+		header, value string
+		code          int
+		body          string
+	}{
+		{"", "", http.StatusOK, "unversioned 7"},
+		{"API-Version", "2", http.StatusOK, "2 7 2"},
+		{"API-Version", "v1", http.StatusOK, "1 7 1"},
+		{"API-Version", "3.1.0", http.StatusOK, "v3.1 7 v3.1"},
+		{"API-Version", "2.5", http.StatusOK, "2 7 2"},
+		{"API-Version", "10", http.StatusOK, "v3.1 7 v3.1"},
+		{"API-Version", "0", http.StatusOK, "unversioned 7"},
+		{"Accept", "application/vnd.acme.v2+json", http.StatusOK, "2 7 2"},
+		{"Accept", "text/html, application/vnd.ACME.v1+json; q=0.9", http.StatusOK, "1 7 1"},
+		{"Accept", "application/vnd.other.v2+json", http.StatusOK, "unversioned 7"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/7", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.code, w.Code, tt.value)
+		assert.Equal(t, tt.body, w.Body.String(), tt.value)
+		assert.Equal(t, []string{"Api-Version, Accept"}, w.Header().Values("Vary"), tt.value)
+	}
+
+	// a single version is only served to the requests asking for it or above
+	for requested, code := range map[string]int{"": http.StatusNotFound, "1": http.StatusNotFound, "2": http.StatusOK, "3": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+		req.Header.Set("API-Version", requested)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, code, w.Code, requested)
+		assert.Equal(t, []string{"Api-Version, Accept"}, w.Header().Values("Vary"), requested)
+	}
+
+	assert.Len(t, router.Routes(), 5)
+	assert.NoError(t, router.Validate())
+}
+
+func TestRouterGroupVersionSingle(t *testing.T) {
+	router := New()
+	selector := HeaderVersion("API-Version")
+	router.Version("2", selector).GET("/users", func(c *Context) {
+		c.String(http.StatusOK, "2")
+	})
+
+	serve := func(requested string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		if requested != "" {
+			req.Header.Set("API-Version", requested)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, "Api-Version", w.Header().Get("Vary"), requested)
+		return w
+	}
+	assert.Equal(t, http.StatusNotFound, serve("").Code)
+	assert.Equal(t, http.StatusNotFound, serve("1").Code)
+	assert.Equal(t, "2", serve("2").Body.String())
+	assert.Equal(t, "2", serve("3").Body.String())
+
+	router.DefaultVersion = "2"
+	assert.Equal(t, "2", serve("").Body.String())
+	assert.Equal(t, "2", serve("1").Body.String())
+
+	// adding a version doesn't change what the requests for the others get
+	router.DefaultVersion = ""
+	router.Version("3", selector).GET("/users", func(c *Context) {
+		c.String(http.StatusOK, "3")
+	})
+	assert.Equal(t, http.StatusNotFound, serve("1").Code)
+	assert.Equal(t, "2", serve("2").Body.String())
+	assert.Equal(t, "3", serve("4").Body.String())
+}
+
+func TestRouterGroupVersionDefault(t *testing.T) {
+	router := New()
+	router.DefaultVersion = "2"
+	selector := HeaderVersion("API-Version")
+	for _, version := range []string{"2", "3"} {
+		router.Version(version, selector).GET("/users", func(c *Context) {
+			c.String(http.StatusOK, version)
+		})
+	}
+	// splits the node holding the versions
+	router.GET("/usage", handlerTest1)
+
+	for requested, body := range map[string]string{"": "2", "1": "2", "3": "3", "4": "3"} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("API-Version", requested)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, body, w.Body.String(), requested)
+		assert.Equal(t, "Api-Version", w.Header().Get("Vary"), requested)
+	}
+
+	router.DefaultVersion = ""
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.NoError(t, router.RemoveRoute(http.MethodGet, "/users"))
+	assert.Len(t, router.Routes(), 1)
+}
+
+func TestRouterGroupVersionConflict(t *testing.T) {
+	router := New()
+	selector := HeaderVersion("API-Version")
+	router.GET("/users", handlerTest1)
+	router.Version("2", selector).GET("/users", handlerTest1)
+
+	assert.PanicsWithValue(t, "handlers are already registered for path '/users'", func() {
+		router.GET("/users", handlerTest1)
+	})
+	assert.PanicsWithValue(t, "handlers are already registered for path '/users' and version 'v2.0'", func() {
+		router.Version("v2.0", selector).GET("/users", handlerTest1)
+	})
+	assert.Panics(t, func() { router.Version("", selector) })
+	assert.Panics(t, func() { router.Version("1") })
+
+	// the routes with other paths are kept apart
+	router.Version("2", selector).GET("/users/:id", handlerTest1)
+	assert.Len(t, router.Routes(), 3)
+}
+
+func TestPickVersion(t *testing.T) {
+	routes := []RouteInfo{{Version: "1"}, {}, {Version: "3"}}
+	for requested, want := range map[string]string{"": "", "1.5": "1", "3": "3", "4": "3"} {
+		route, ok := PickVersion(routes, requested, "")
+		assert.True(t, ok, requested)
+		assert.Equal(t, want, route.Version, requested)
+	}
+	route, ok := PickVersion(routes, "", "3")
+	assert.True(t, ok)
+	assert.Equal(t, "3", route.Version)
+
+	// a single version is not picked below it
+	routes = []RouteInfo{{Version: "2"}}
+	_, ok = PickVersion(routes, "1", "")
+	assert.False(t, ok)
+	_, ok = PickVersion(routes, "", "")
+	assert.False(t, ok)
+	route, ok = PickVersion(routes, "1", "2")
+	assert.True(t, ok)
+	assert.Equal(t, "2", route.Version)
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("v2", "2.0"))
+	assert.Equal(t, -1, compareVersions("2", "10"))
+	assert.Equal(t, 1, compareVersions("1.10", "1.9"))
+	assert.Equal(t, -1, compareVersions("1.0-beta", "1.0-rc"))
+}